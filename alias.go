@@ -0,0 +1,114 @@
+package pobj
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Alias makes this object also reachable at path, in addition to its primary
+// path (returned by String). The alias shares the Object, including its type,
+// actions, methods and children. This is typically used to expose a type
+// under an older name during API migrations:
+//
+//	pobj.Register[User]("user").Alias("legacy/user")
+//
+// Returns the object for chaining. Panics if the alias cannot be created, see
+// TryAlias.
+func (o *Object) Alias(path string) *Object {
+	if err := o.TryAlias(path); err != nil {
+		panic(err)
+	}
+	return o
+}
+
+// TryAlias is like Alias but returns a *RegistrationError wrapping
+// ErrInvalidPath or ErrDuplicatePath instead of panicking. An alias cannot
+// replace an existing object, even if it has no type.
+func (o *Object) TryAlias(path string) error {
+	if o == nil {
+		return ErrUnknownType
+	}
+	pa, err := ParsePath(path)
+	if err != nil {
+		return &RegistrationError{Path: path, Type: o.rtype(), Err: err}
+	}
+	r := o.reg
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if x := r.lookup(pa, false); x != nil {
+		return &RegistrationError{Path: path, Type: o.rtype(), Existing: x.rtype(), Err: ErrDuplicatePath}
+	}
+	if parent := r.lookup(pa[:len(pa)-1], false); parent != nil && o.reaches(parent) {
+		err := fmt.Errorf("%w %q: alias would create a cycle", ErrInvalidPath, path)
+		return &RegistrationError{Path: path, Type: o.rtype(), Err: err}
+	}
+
+	parent := r.lookup(pa[:len(pa)-1], true)
+	parent.children.set(pa[len(pa)-1], o)
+	o.aliases.set(strings.Join(pa, "/"), parent)
+	return nil
+}
+
+// Aliases returns the alias paths of this object, sorted.
+// Returns nil if the object has no aliases.
+func (o *Object) Aliases() []string {
+	if o == nil {
+		return nil
+	}
+	m := o.aliases.load()
+	if len(m) == 0 {
+		return nil
+	}
+	res := make([]string, 0, len(m))
+	for p := range m {
+		res = append(res, p)
+	}
+	sort.Strings(res)
+	return res
+}
+
+// isAlias returns true if path (in normalized form) is an alias of o.
+func (o *Object) isAlias(path string) bool {
+	_, ok := o.aliases.get(path)
+	return ok
+}
+
+// reaches returns true if target is o or can be reached from o by following
+// children, including aliases.
+func (o *Object) reaches(target *Object) bool {
+	seen := make(map[*Object]bool)
+	var walk func(*Object) bool
+	walk = func(c *Object) bool {
+		if c == target {
+			return true
+		}
+		if seen[c] {
+			return false
+		}
+		seen[c] = true
+		for _, child := range c.children.load() {
+			if walk(child) {
+				return true
+			}
+		}
+		return false
+	}
+	return walk(o)
+}
+
+// removeAlias removes the alias path of o from the tree, pruning the
+// intermediate objects left empty. Caller must hold r.mu.
+func (r *Registry) removeAlias(o *Object, path string) {
+	parent, ok := o.aliases.get(path)
+	if !ok {
+		return
+	}
+	name := path[strings.LastIndexByte(path, '/')+1:]
+	if cur, ok := parent.children.get(name); ok && cur == o {
+		parent.children.delete(name)
+	}
+	o.aliases.delete(path)
+	r.prune(parent)
+}
@@ -0,0 +1,91 @@
+package pobj_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/KarpelesLab/pobj"
+)
+
+func TestAlias(t *testing.T) {
+	r := pobj.NewRegistry()
+
+	obj := pobj.RegisterIn[TestPerson](r, "user").Alias("legacy/user").Alias("v1/person")
+	r.RegisterMethod("user:hello", func() {})
+
+	for _, p := range []string{"user", "legacy/user", "v1/person"} {
+		if r.Get(p) != obj {
+			t.Errorf("Get(%q) did not return the aliased object", p)
+		}
+	}
+	if obj.String() != "user" {
+		t.Errorf("Wrong primary path, got %s, want user", obj.String())
+	}
+	if r.Get("legacy/user").Method("hello") == nil {
+		t.Error("Method not reachable through alias")
+	}
+	if !reflect.DeepEqual(obj.Aliases(), []string{"legacy/user", "v1/person"}) {
+		t.Errorf("Wrong aliases, got %v", obj.Aliases())
+	}
+	if all := pobj.GetAllByTypeIn[TestPerson](r); len(all) != 1 || all[0] != obj {
+		t.Errorf("Aliases should not count as registrations, got %v", all)
+	}
+	if len(r.All()) != 1 {
+		t.Errorf("Wrong number of objects, got %d, want 1", len(r.All()))
+	}
+
+	// Conflicts
+	if err := obj.TryAlias("user"); !errors.Is(err, pobj.ErrDuplicatePath) {
+		t.Errorf("Wrong error, got %v, want %v", err, pobj.ErrDuplicatePath)
+	}
+	if err := obj.TryAlias("user/self"); !errors.Is(err, pobj.ErrInvalidPath) {
+		t.Errorf("Wrong error for cycle, got %v, want %v", err, pobj.ErrInvalidPath)
+	}
+	if err := obj.TryAlias("bad//path"); !errors.Is(err, pobj.ErrInvalidPath) {
+		t.Errorf("Wrong error, got %v, want %v", err, pobj.ErrInvalidPath)
+	}
+
+	// Removing an alias keeps the object
+	if !r.Unregister("legacy/user") {
+		t.Fatal("Unregister of alias returned false")
+	}
+	if r.Get("legacy") != nil {
+		t.Error("Alias parent was not pruned")
+	}
+	if r.Get("user") != obj || pobj.GetByTypeIn[TestPerson](r) != obj {
+		t.Error("Removing an alias should keep the object")
+	}
+
+	// Removing the object removes the remaining aliases
+	if !r.Unregister("user") {
+		t.Fatal("Unregister returned false")
+	}
+	if r.Get("v1/person") != nil || r.Get("v1") != nil {
+		t.Error("Aliases were not removed with their object")
+	}
+}
+
+func TestGetAllByType(t *testing.T) {
+	r := pobj.NewRegistry()
+
+	first := pobj.RegisterIn[TestPerson](r, "user")
+	second := pobj.RegisterIn[TestPerson](r, "v2/user")
+
+	all := pobj.GetAllByTypeIn[TestPerson](r)
+	if len(all) != 2 || all[0] != first || all[1] != second {
+		t.Fatalf("Wrong registrations, got %v", all)
+	}
+	// The most recent registration wins
+	if pobj.GetByTypeIn[TestPerson](r) != second {
+		t.Error("GetByType should return the most recent registration")
+	}
+
+	r.Unregister("v2/user")
+	if pobj.GetByTypeIn[TestPerson](r) != first {
+		t.Error("GetByType should fall back to the remaining registration")
+	}
+	if pobj.GetAllByTypeIn[TestCompany](r) != nil {
+		t.Error("Expected nil for unregistered type")
+	}
+}
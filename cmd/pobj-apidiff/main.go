@@ -0,0 +1,78 @@
+// pobj-apidiff compares two registry manifests, as written by pobj-manifest,
+// and reports the changes of the API.
+//
+// Usage:
+//
+//	pobj-apidiff [-breaking] old.json new.json
+//
+// Every change is printed on its own line, breaking changes being prefixed
+// with "BREAKING". With -breaking, only breaking changes are printed.
+//
+// The exit code is 1 if there are breaking changes, and 2 on errors, so it
+// can be used to gate CI:
+//
+//	git show main:pobj.json > /tmp/old.json
+//	go run github.com/KarpelesLab/pobj/cmd/pobj-manifest -o pobj.json ./models
+//	go run github.com/KarpelesLab/pobj/cmd/pobj-apidiff /tmp/old.json pobj.json
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/KarpelesLab/pobj"
+	"github.com/KarpelesLab/pobj/pobjdiff"
+)
+
+func main() {
+	breakingOnly := flag.Bool("breaking", false, "only print breaking changes")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: pobj-apidiff [-breaking] old.json new.json\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 2 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	changes, err := run(flag.Arg(0), flag.Arg(1))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "pobj-apidiff: %v\n", err)
+		os.Exit(2)
+	}
+	for _, c := range changes {
+		if c.Breaking || !*breakingOnly {
+			fmt.Println(c)
+		}
+	}
+	if pobjdiff.HasBreaking(changes) {
+		os.Exit(1)
+	}
+}
+
+func run(oldFile, newFile string) ([]*pobjdiff.Change, error) {
+	old, err := load(oldFile)
+	if err != nil {
+		return nil, err
+	}
+	new, err := load(newFile)
+	if err != nil {
+		return nil, err
+	}
+	return pobjdiff.Compare(old, new), nil
+}
+
+func load(name string) (*pobj.Manifest, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	m, err := pobj.LoadManifest(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return m, nil
+}
@@ -8,6 +8,11 @@
 // pobj.RegisterMethod calls, finds the associated godoc comments for the registered
 // types and functions, and generates a pobj_doc.go file with init() that sets
 // the documentation.
+//
+// Package-level functions named after a registered type using the
+// TypeName_methodName convention are registered as static methods of that
+// type. For example, with pobj.Register[User]("user"), the function
+// User_getByEmail is registered as "user:getByEmail".
 package main
 
 import (
@@ -21,6 +26,7 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
 )
 
@@ -61,7 +67,7 @@ func run(pkgDir, outputFile string) error {
 			return err
 		}
 
-		if len(docs.types) == 0 && len(docs.methods) == 0 {
+		if len(docs.types) == 0 && len(docs.methods) == 0 && len(docs.statics) == 0 {
 			fmt.Printf("pobj-docgen: no pobj registrations found in package %s\n", pkgName)
 			continue
 		}
@@ -83,8 +89,8 @@ func run(pkgDir, outputFile string) error {
 			fieldCount += len(td.fields)
 		}
 
-		fmt.Printf("pobj-docgen: generated %s with %d type docs, %d field docs, and %d method docs\n",
-			outPath, len(docs.types), fieldCount, len(docs.methods))
+		fmt.Printf("pobj-docgen: generated %s with %d type docs, %d field docs, %d method docs, and %d static methods\n",
+			outPath, len(docs.types), fieldCount, len(docs.methods), len(docs.statics))
 	}
 
 	return nil
@@ -93,6 +99,20 @@ func run(pkgDir, outputFile string) error {
 type docInfo struct {
 	types   map[string]typeDoc   // registration path -> doc info
 	methods map[string]methodDoc // "Object:method" -> doc info
+	statics []staticFunc         // static methods registered by naming convention
+
+	typePaths  map[string]string // type name -> registration path
+	registered map[string]bool   // "Object:method" paths registered with RegisterMethod
+}
+
+// staticFunc is a package-level function following the TypeName_methodName
+// naming convention.
+type staticFunc struct {
+	typeName string // registered type name
+	method   string // method name
+	funcName string // Go function name
+	path     string // full "Object:method" path, set once the type is found
+	doc      string // documentation
 }
 
 type typeDoc struct {
@@ -114,9 +134,12 @@ type typeInfo struct {
 
 func extractDocs(pkg *ast.Package) (*docInfo, error) {
 	info := &docInfo{
-		types:   make(map[string]typeDoc),
-		methods: make(map[string]methodDoc),
+		types:      make(map[string]typeDoc),
+		methods:    make(map[string]methodDoc),
+		typePaths:  make(map[string]string),
+		registered: make(map[string]bool),
 	}
+	var candidates []staticFunc
 
 	// First pass: build maps of type and function documentation
 	typeInfos := make(map[string]*typeInfo) // type name -> info
@@ -177,6 +200,16 @@ func extractDocs(pkg *ast.Package) (*docInfo, error) {
 				if d.Doc != nil {
 					funcDocs[d.Name.Name] = strings.TrimSpace(d.Doc.Text())
 				}
+				if d.Recv == nil {
+					if typeName, method, ok := strings.Cut(d.Name.Name, "_"); ok && typeName != "" && method != "" {
+						candidates = append(candidates, staticFunc{
+							typeName: typeName,
+							method:   method,
+							funcName: d.Name.Name,
+							doc:      funcDocs[d.Name.Name],
+						})
+					}
+				}
 			}
 		}
 	}
@@ -215,9 +248,9 @@ func extractDocs(pkg *ast.Package) (*docInfo, error) {
 			}
 
 			switch funcName {
-			case "Register", "RegisterActions":
+			case "Register", "RegisterActions", "TryRegister", "TryRegisterActions", "Replace":
 				info.processRegister(call, typeInfos)
-			case "RegisterMethod", "RegisterStatic":
+			case "RegisterMethod", "RegisterStatic", "TryRegisterMethod":
 				info.processRegisterMethod(call, funcDocs, varFuncs)
 			}
 
@@ -225,6 +258,21 @@ func extractDocs(pkg *ast.Package) (*docInfo, error) {
 		})
 	}
 
+	// Resolve static methods declared by naming convention, unless they were
+	// registered explicitly
+	for _, sf := range candidates {
+		path, ok := info.typePaths[sf.typeName]
+		if !ok {
+			continue
+		}
+		sf.path = path + ":" + sf.method
+		if info.registered[sf.path] {
+			continue
+		}
+		info.statics = append(info.statics, sf)
+	}
+	sort.Slice(info.statics, func(i, j int) bool { return info.statics[i].path < info.statics[j].path })
+
 	return info, nil
 }
 
@@ -250,6 +298,7 @@ func (info *docInfo) processRegister(call *ast.CallExpr, typeInfos map[string]*t
 	if typeName == "" {
 		return
 	}
+	info.typePaths[typeName] = path
 
 	if ti, ok := typeInfos[typeName]; ok {
 		info.types[path] = typeDoc{
@@ -271,6 +320,7 @@ func (info *docInfo) processRegisterMethod(call *ast.CallExpr, funcDocs map[stri
 	if path == "" || !strings.Contains(path, ":") {
 		return
 	}
+	info.registered[path] = true
 
 	// Get the function name from second argument
 	funcName := ""
@@ -339,19 +389,32 @@ func generateOutput(pkgName string, docs *docInfo) ([]byte, error) {
 	buf.WriteString("import \"github.com/KarpelesLab/pobj\"\n\n")
 	buf.WriteString("func init() {\n")
 
-	// Generate SetDoc calls for types and their fields
-	for _, td := range docs.types {
+	// Register static methods declared by naming convention
+	for _, sf := range docs.statics {
+		buf.WriteString(fmt.Sprintf("\tpobj.RegisterMethod(%q, %s)", sf.path, sf.funcName))
+		if sf.doc != "" {
+			buf.WriteString(fmt.Sprintf(".SetDoc(%s)", formatDoc(sf.doc)))
+		}
+		buf.WriteString("\n")
+	}
+
+	// Generate SetDoc calls for types and their fields, sorted so the output
+	// is the same on every run
+	for _, path := range sortedKeys(docs.types) {
+		td := docs.types[path]
 		if td.doc != "" {
 			buf.WriteString(fmt.Sprintf("\tpobj.Get(%q).SetDoc(%s)\n", td.path, formatDoc(td.doc)))
 		}
 		// Generate SetFieldDoc calls for each field
-		for fieldName, fieldDoc := range td.fields {
+		for _, fieldName := range sortedKeys(td.fields) {
+			fieldDoc := td.fields[fieldName]
 			buf.WriteString(fmt.Sprintf("\tpobj.Get(%q).SetFieldDoc(%q, %s)\n", td.path, fieldName, formatDoc(fieldDoc)))
 		}
 	}
 
 	// Generate SetDoc calls for methods
-	for _, md := range docs.methods {
+	for _, path := range sortedKeys(docs.methods) {
+		md := docs.methods[path]
 		parts := strings.SplitN(md.path, ":", 2)
 		if len(parts) != 2 {
 			continue
@@ -366,6 +429,16 @@ func generateOutput(pkgName string, docs *docInfo) ([]byte, error) {
 	return format.Source(buf.Bytes())
 }
 
+// sortedKeys returns the keys of m in sorted order.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 var needsRawString = regexp.MustCompile("[`]")
 
 func formatDoc(doc string) string {
@@ -0,0 +1,83 @@
+// pobj-manifest writes the manifest of the objects registered by the given
+// packages, as returned by Registry.Manifest, in JSON format.
+//
+// Usage:
+//
+//	pobj-manifest [-o manifest.json] [-base manifest.json] [packages]
+//
+// Like pobj-openapi, the packages (by default, the package in the current
+// directory) must be part of the current module and register their objects
+// in init functions. The manifest can then be used by tools that do not
+// import them, such as pobj-ts -manifest.
+//
+// With -base, the type IDs of a previous manifest are kept (see
+// pobj.LoadTypeIDs), and new types get new IDs. The base manifest is
+// ignored if it does not exist yet, so the output can be used as base:
+//
+//	//go:generate go run github.com/KarpelesLab/pobj/cmd/pobj-manifest -base pobj.json -o pobj.json
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/KarpelesLab/pobj/internal/gorun"
+)
+
+func main() {
+	outputFile := flag.String("o", "", "output file name (default: standard output)")
+	baseFile := flag.String("base", "", "previous manifest whose type IDs are kept")
+	flag.Parse()
+
+	if err := run(flag.Args(), *outputFile, *baseFile); err != nil {
+		fmt.Fprintf(os.Stderr, "pobj-manifest: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(patterns []string, outputFile, baseFile string) error {
+	pkgs, err := gorun.ImportPaths(patterns)
+	if err != nil {
+		return err
+	}
+	imports := []string{`"encoding/json"`, `"os"`, `"github.com/KarpelesLab/pobj"`}
+	for _, pkg := range pkgs {
+		imports = append(imports, "_ "+strconv.Quote(pkg))
+	}
+	var body string
+	if baseFile != "" {
+		if _, err := os.Stat(baseFile); err == nil {
+			body = fmt.Sprintf(`	f, err := os.Open(%q)
+	if err != nil {
+		panic(err)
+	}
+	base, err := pobj.LoadManifest(f)
+	if err != nil {
+		panic(err)
+	}
+	if err := pobj.LoadTypeIDs(base); err != nil {
+		panic(err)
+	}
+`, baseFile)
+		} else if !os.IsNotExist(err) {
+			return err
+		}
+	}
+	body += `	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(pobj.DefaultRegistry.Manifest()); err != nil {
+		panic(err)
+	}`
+
+	out, err := gorun.Run(imports, body)
+	if err != nil {
+		return err
+	}
+	if outputFile == "" {
+		_, err = os.Stdout.Write(out)
+		return err
+	}
+	return os.WriteFile(outputFile, out, 0644)
+}
@@ -0,0 +1,72 @@
+// pobj-openapi generates an OpenAPI 3.1 document describing the routes
+// served by pobjhttp for the objects registered by the given packages.
+//
+// Usage:
+//
+//	pobj-openapi [-o openapi.json] [-title API] [-version 1.0.0] [-server URL] [packages]
+//
+// The packages (by default, the package in the current directory) must be
+// part of the current module and register their objects in init functions.
+// The tool runs a small generated program importing them, so the registry
+// and the documentation generated by pobj-docgen are the same as at run time.
+//
+// It can be used with go:generate:
+//
+//	//go:generate go run github.com/KarpelesLab/pobj/cmd/pobj-openapi -o openapi.json
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/KarpelesLab/pobj/internal/gorun"
+)
+
+func main() {
+	var (
+		outputFile = flag.String("o", "", "output file name (default: standard output)")
+		title      = flag.String("title", "API", "title of the API")
+		version    = flag.String("version", "1.0.0", "version of the API")
+		server     = flag.String("server", "", "base URL the API is served at")
+	)
+	flag.Parse()
+
+	if err := run(flag.Args(), *outputFile, *title, *version, *server); err != nil {
+		fmt.Fprintf(os.Stderr, "pobj-openapi: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(patterns []string, outputFile, title, version, server string) error {
+	pkgs, err := gorun.ImportPaths(patterns)
+	if err != nil {
+		return err
+	}
+	imports := []string{`"encoding/json"`, `"os"`, `"github.com/KarpelesLab/pobj/pobjhttp"`}
+	for _, pkg := range pkgs {
+		imports = append(imports, "_ "+strconv.Quote(pkg))
+	}
+
+	servers := ""
+	if server != "" {
+		servers = fmt.Sprintf(", pobjhttp.Server{URL: %q}", server)
+	}
+	body := fmt.Sprintf(`	doc := pobjhttp.New(nil).OpenAPI(pobjhttp.Info{Title: %q, Version: %q}%s)
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		panic(err)
+	}`, title, version, servers)
+
+	out, err := gorun.Run(imports, body)
+	if err != nil {
+		return err
+	}
+	if outputFile == "" {
+		_, err = os.Stdout.Write(out)
+		return err
+	}
+	return os.WriteFile(outputFile, out, 0644)
+}
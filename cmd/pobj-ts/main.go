@@ -0,0 +1,119 @@
+// pobj-ts generates TypeScript type definitions and a typed client for the
+// routes served by pobjhttp, for the objects registered by the given
+// packages.
+//
+// Usage:
+//
+//	pobj-ts [-o dir] [packages]
+//	pobj-ts [-o dir] -manifest manifest.json
+//
+// Two files are written to the output directory: types.d.ts with an
+// interface per registered type, and client.ts with a Client class having
+// one function per action and method. Docs are carried as TSDoc comments.
+//
+// The code is generated from the registry manifest (see
+// pobj.Registry.Manifest). It is obtained like pobj-manifest does: the
+// packages (by default, the package in the current directory) must be part
+// of the current module and register their objects in init functions.
+// Alternatively, the manifest can be read from a file written by
+// pobj-manifest, for instance when the client is built in another
+// repository.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/KarpelesLab/pobj"
+	"github.com/KarpelesLab/pobj/internal/gorun"
+	"github.com/KarpelesLab/pobj/pobjts"
+)
+
+func main() {
+	outputDir := flag.String("o", ".", "output directory")
+	manifest := flag.String("manifest", "", "generate from a manifest file instead of packages")
+	flag.Parse()
+
+	var err error
+	if *manifest != "" {
+		err = runManifest(*manifest, *outputDir)
+	} else {
+		err = run(flag.Args(), *outputDir)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "pobj-ts: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// run generates the code from the manifest of the objects registered by the
+// packages matching patterns.
+func run(patterns []string, outputDir string) error {
+	pkgs, err := gorun.ImportPaths(patterns)
+	if err != nil {
+		return err
+	}
+	imports := []string{`"encoding/json"`, `"os"`, `"github.com/KarpelesLab/pobj"`}
+	for _, pkg := range pkgs {
+		imports = append(imports, "_ "+strconv.Quote(pkg))
+	}
+	body := `	if err := json.NewEncoder(os.Stdout).Encode(pobj.DefaultRegistry.Manifest()); err != nil {
+		panic(err)
+	}`
+
+	out, err := gorun.Run(imports, body)
+	if err != nil {
+		return err
+	}
+	m, err := pobj.LoadManifest(bytes.NewReader(out))
+	if err != nil {
+		return err
+	}
+	return generate(m, outputDir)
+}
+
+// runManifest generates the code from the manifest in file manifest.
+func runManifest(manifest, outputDir string) error {
+	f, err := os.Open(manifest)
+	if err != nil {
+		return err
+	}
+	m, err := pobj.LoadManifest(f)
+	f.Close()
+	if err != nil {
+		return err
+	}
+	return generate(m, outputDir)
+}
+
+// generate writes the code for manifest m to outputDir.
+func generate(m *pobj.Manifest, outputDir string) error {
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return err
+	}
+
+	g := pobjts.FromManifest(m)
+	write := func(name string, fn func(f *os.File) error) error {
+		f, err := os.Create(filepath.Join(outputDir, name))
+		if err != nil {
+			return err
+		}
+		if err := fn(f); err != nil {
+			f.Close()
+			return err
+		}
+		return f.Close()
+	}
+	if err := write("types.d.ts", func(f *os.File) error { return g.WriteTypes(f) }); err != nil {
+		return err
+	}
+	if err := write("client.ts", func(f *os.File) error { return g.WriteClient(f, "./types") }); err != nil {
+		return err
+	}
+	fmt.Printf("pobj-ts: generated %s and %s\n", filepath.Join(outputDir, "types.d.ts"), filepath.Join(outputDir, "client.ts"))
+	return nil
+}
@@ -0,0 +1,109 @@
+package pobj
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+// encMode is the CBOR encoding mode of Encoder: deterministic, so equal
+// values have equal encodings.
+var encMode, _ = cbor.CoreDetEncOptions().EncMode()
+
+// Encoder writes values of registered types to a stream in a compact binary
+// format: for each value, its type ID (see Object.TypeID) followed by its
+// CBOR encoding, both as CBOR data items. Struct fields are named after
+// their cbor tag, or their json tag if they have none.
+type Encoder struct {
+	r   *Registry
+	enc *cbor.Encoder
+}
+
+// NewEncoder returns an Encoder writing to w, for the types of the
+// DefaultRegistry.
+func NewEncoder(w io.Writer) *Encoder {
+	return DefaultRegistry.NewEncoder(w)
+}
+
+// NewEncoder returns an Encoder writing to w, for the types of r.
+func (r *Registry) NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{r: r, enc: encMode.NewEncoder(w)}
+}
+
+// Encode writes v, a value or a pointer to a value of a registered type. If
+// the type is registered at several paths, the most recent registration is
+// used, as with GetByType. A nil v, including a nil pointer, is written with
+// type ID 0.
+//
+// Returns an error wrapping ErrUnknownType if the type of v is not
+// registered.
+func (e *Encoder) Encode(v any) error {
+	if isNil(v) {
+		v = nil
+	}
+	var id uint64
+	if v != nil {
+		o := e.r.GetByType(reflect.TypeOf(v))
+		if o == nil {
+			return fmt.Errorf("%w: %T", ErrUnknownType, v)
+		}
+		id = o.TypeID()
+	}
+	if err := e.enc.Encode(id); err != nil {
+		return err
+	}
+	return e.enc.Encode(v)
+}
+
+// Decoder reads values written by an Encoder.
+type Decoder struct {
+	r   *Registry
+	dec *cbor.Decoder
+}
+
+// NewDecoder returns a Decoder reading from rd, for the types of the
+// DefaultRegistry.
+func NewDecoder(rd io.Reader) *Decoder {
+	return DefaultRegistry.NewDecoder(rd)
+}
+
+// NewDecoder returns a Decoder reading from rd, for the types of r.
+func (r *Registry) NewDecoder(rd io.Reader) *Decoder {
+	return &Decoder{r: r, dec: cbor.NewDecoder(rd)}
+}
+
+// Decode reads the next value. The value is allocated with New on the
+// object with its type ID (see ByTypeID), and is returned as a pointer, such
+// as *User. A value written with type ID 0 is returned as nil.
+//
+// Returns io.EOF at the end of the stream, and an error wrapping
+// ErrUnknownType if no type is registered with the ID of the value. In the
+// latter case the value is skipped, and the next one can be decoded.
+func (d *Decoder) Decode() (any, error) {
+	var id uint64
+	if err := d.dec.Decode(&id); err != nil {
+		return nil, err
+	}
+	var data cbor.RawMessage
+	if err := d.dec.Decode(&data); err != nil {
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	if id == 0 {
+		return nil, nil
+	}
+	o := d.r.ByTypeID(id)
+	if o == nil {
+		return nil, fmt.Errorf("%w: type ID %d", ErrUnknownType, id)
+	}
+	v := o.New()
+	if err := cbor.Unmarshal(data, v); err != nil {
+		return nil, fmt.Errorf("pobj: decoding %s: %w", o, err)
+	}
+	return v, nil
+}
@@ -0,0 +1,153 @@
+package pobj_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/KarpelesLab/pobj"
+)
+
+type codecItem struct {
+	ID    string   `json:"id"`
+	Count int      `json:"count"`
+	Tags  []string `json:"tags,omitempty"`
+}
+
+type codecEvent struct {
+	Name string `cbor:"n"`
+	At   int64  `cbor:"t"`
+}
+
+func TestTypeID(t *testing.T) {
+	r := pobj.NewRegistry()
+	item := pobj.RegisterIn[codecItem](r, "cache/item")
+	event := pobj.RegisterIn[codecEvent](r, "cache/event")
+
+	if item.TypeID() != 1 || event.TypeID() != 2 || item.TypeID() != 1 {
+		t.Errorf("wrong type IDs, got %d and %d", item.TypeID(), event.TypeID())
+	}
+	if r.Get("cache").TypeID() != 0 || (*pobj.Object)(nil).TypeID() != 0 {
+		t.Error("objects without type should have no type ID")
+	}
+	if r.ByTypeID(2) != event || r.ByTypeID(3) != nil || r.ByTypeID(0) != nil {
+		t.Error("wrong ByTypeID result")
+	}
+
+	// IDs are kept by path
+	r.Unregister("cache/item")
+	if r.ByTypeID(1) != nil {
+		t.Error("unregistered object found by type ID")
+	}
+	if id := pobj.RegisterIn[codecEvent](r, "cache/item").TypeID(); id != 1 {
+		t.Errorf("wrong type ID after registering again, got %d", id)
+	}
+}
+
+func TestLoadTypeIDs(t *testing.T) {
+	r := pobj.NewRegistry()
+	pobj.RegisterIn[codecItem](r, "cache/item")
+	pobj.RegisterIn[codecEvent](r, "cache/event")
+	r.RegisterMethod("tools:ping", func() {})
+
+	// Manifest assigns the IDs in path order
+	m := r.Manifest()
+	var ids []uint64
+	for _, om := range m.Objects {
+		ids = append(ids, om.TypeID)
+	}
+	if !reflect.DeepEqual(ids, []uint64{1, 2, 0}) {
+		t.Errorf("wrong manifest type IDs, got %v", ids)
+	}
+
+	// a later version adds a type sorting first
+	next := pobj.NewRegistry()
+	if err := next.LoadTypeIDs(m); err != nil {
+		t.Fatalf("LoadTypeIDs failed: %v", err)
+	}
+	a := pobj.RegisterIn[codecItem](next, "cache/a")
+	item := pobj.RegisterIn[codecItem](next, "cache/item")
+	if a.TypeID() != 3 || item.TypeID() != 2 || next.ByTypeID(1) != nil {
+		t.Errorf("wrong type IDs after loading, got %d and %d", a.TypeID(), item.TypeID())
+	}
+	if err := next.LoadTypeIDs(m); err != nil {
+		t.Errorf("loading the same IDs again failed: %v", err)
+	}
+
+	conflicts := []*pobj.Manifest{
+		{Objects: []*pobj.ObjectManifest{{Path: "cache/a", TypeID: 1}}},
+		{Objects: []*pobj.ObjectManifest{{Path: "cache/other", TypeID: 2}}},
+		{Objects: []*pobj.ObjectManifest{{Path: "x", TypeID: 9}, {Path: "y", TypeID: 9}}},
+	}
+	for _, c := range conflicts {
+		if err := next.LoadTypeIDs(c); !errors.Is(err, pobj.ErrTypeIDConflict) {
+			t.Errorf("wrong error for %s, got %v", c.Objects[0].Path, err)
+		}
+	}
+	if next.ByTypeID(9) != nil || pobj.RegisterIn[codecItem](next, "x").TypeID() != 4 {
+		t.Error("IDs loaded despite a conflict")
+	}
+}
+
+func TestEncoder(t *testing.T) {
+	r := pobj.NewRegistry()
+	pobj.RegisterIn[codecItem](r, "cache/item")
+	pobj.RegisterIn[codecEvent](r, "cache/event")
+
+	var buf bytes.Buffer
+	enc := r.NewEncoder(&buf)
+	values := []any{
+		&codecItem{ID: "a", Count: 2, Tags: []string{"x"}},
+		codecEvent{Name: "start", At: 1000},
+		nil,
+		&codecItem{ID: "b"},
+		(*codecItem)(nil),
+	}
+	for _, v := range values {
+		if err := enc.Encode(v); err != nil {
+			t.Fatalf("Encode failed: %v", err)
+		}
+	}
+	if err := enc.Encode(&TestPerson{}); !errors.Is(err, pobj.ErrUnknownType) {
+		t.Errorf("wrong error for an unregistered type, got %v", err)
+	}
+	// type ID, then the payload with the cbor or json field names
+	if want := []byte("\x01\xa3\x62id\x61a\x64tags\x81\x61x\x65count\x02"); !bytes.HasPrefix(buf.Bytes(), want) {
+		t.Errorf("wrong encoding, got %x", buf.Bytes())
+	}
+
+	// decode with the IDs of the manifest in another registry
+	other := pobj.NewRegistry()
+	if err := other.LoadTypeIDs(r.Manifest()); err != nil {
+		t.Fatal(err)
+	}
+	pobj.RegisterIn[codecItem](other, "cache/item")
+
+	dec := other.NewDecoder(bytes.NewReader(buf.Bytes()))
+	want := []any{&codecItem{ID: "a", Count: 2, Tags: []string{"x"}}, nil, nil, &codecItem{ID: "b"}, nil}
+	for i, w := range want {
+		v, err := dec.Decode()
+		if i == 1 {
+			// cache/event is not registered in other
+			if !errors.Is(err, pobj.ErrUnknownType) {
+				t.Errorf("wrong error for an unknown type ID, got %v", err)
+			}
+			continue
+		}
+		if err != nil || !reflect.DeepEqual(v, w) {
+			t.Errorf("value %d: got %#v, %v, want %#v", i, v, err, w)
+		}
+	}
+	if _, err := dec.Decode(); err != io.EOF {
+		t.Errorf("expected io.EOF at the end, got %v", err)
+	}
+
+	if _, err := r.NewDecoder(bytes.NewReader([]byte{0x01})).Decode(); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("expected io.ErrUnexpectedEOF for a truncated stream, got %v", err)
+	}
+	if v, err := r.NewDecoder(bytes.NewReader(buf.Bytes())).Decode(); err != nil || !reflect.DeepEqual(v, values[0]) {
+		t.Errorf("Decode failed, got %#v, %v", v, err)
+	}
+}
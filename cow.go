@@ -0,0 +1,71 @@
+package pobj
+
+import "sync/atomic"
+
+// cowMap is a copy-on-write map. Reads are lock-free atomic loads of an
+// immutable snapshot, while writes copy the current snapshot, modify the copy
+// and publish it. Writers must be serialized by the caller (typically by
+// holding the owning Registry's lock).
+type cowMap[K comparable, V any] struct {
+	p atomic.Pointer[map[K]V]
+}
+
+// load returns the current snapshot, which may be nil. The returned map must
+// not be modified.
+func (c *cowMap[K, V]) load() map[K]V {
+	if m := c.p.Load(); m != nil {
+		return *m
+	}
+	return nil
+}
+
+// get returns the value stored for k in the current snapshot.
+func (c *cowMap[K, V]) get(k K) (V, bool) {
+	v, ok := c.load()[k]
+	return v, ok
+}
+
+// set publishes a new snapshot with k set to v.
+func (c *cowMap[K, V]) set(k K, v V) {
+	old := c.load()
+	m := make(map[K]V, len(old)+1)
+	for ek, ev := range old {
+		m[ek] = ev
+	}
+	m[k] = v
+	c.p.Store(&m)
+}
+
+// delete publishes a new snapshot without k. Deleting the last key leaves an
+// empty (non-nil) snapshot.
+func (c *cowMap[K, V]) delete(k K) {
+	old := c.load()
+	if _, ok := old[k]; !ok {
+		return
+	}
+	m := make(map[K]V, len(old))
+	for ek, ev := range old {
+		if ek != k {
+			m[ek] = ev
+		}
+	}
+	c.p.Store(&m)
+}
+
+// store publishes m as the new snapshot. m must not be modified afterwards.
+func (c *cowMap[K, V]) store(m map[K]V) {
+	c.p.Store(&m)
+}
+
+// reset publishes an empty (nil) snapshot.
+func (c *cowMap[K, V]) reset() {
+	c.p.Store(nil)
+}
+
+// loadString returns the string stored in p, or an empty string.
+func loadString(p *atomic.Pointer[string]) string {
+	if s := p.Load(); s != nil {
+		return *s
+	}
+	return ""
+}
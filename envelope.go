@@ -0,0 +1,114 @@
+package pobj
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// Envelope is the JSON encoding of a value of a registered type, along with
+// the path of the type, as produced by MarshalEnvelope:
+//
+//	{"type":"user/admin","data":{"id":"42","name":"John"}}
+type Envelope struct {
+	Type string          `json:"type"` // Path of the object the type is registered at
+	Data json.RawMessage `json:"data"` // JSON encoding of the value
+}
+
+// MarshalEnvelope returns the JSON envelope of v using the DefaultRegistry.
+// See Registry.MarshalEnvelope.
+func MarshalEnvelope(v any) ([]byte, error) {
+	return DefaultRegistry.MarshalEnvelope(v)
+}
+
+// MarshalEnvelope returns the JSON encoding of v, a value or a pointer to a
+// value of a registered type, in an Envelope holding the path of the type.
+// If the type is registered at several paths, the most recent registration
+// is used, as with GetByType. A nil v, including a nil pointer, is encoded
+// as null.
+//
+// Returns an error wrapping ErrUnknownType if the type of v is not
+// registered.
+func (r *Registry) MarshalEnvelope(v any) ([]byte, error) {
+	if isNil(v) {
+		return []byte("null"), nil
+	}
+	o := r.GetByType(reflect.TypeOf(v))
+	if o == nil {
+		return nil, fmt.Errorf("%w: %T", ErrUnknownType, v)
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(&Envelope{Type: o.String(), Data: data})
+}
+
+// UnmarshalEnvelope decodes a JSON envelope using the DefaultRegistry. See
+// Registry.UnmarshalEnvelope.
+func UnmarshalEnvelope(data []byte) (any, error) {
+	return DefaultRegistry.UnmarshalEnvelope(data)
+}
+
+// UnmarshalEnvelope decodes a JSON envelope produced by MarshalEnvelope. The
+// value is allocated with New on the object at the envelope's path, which
+// may be an alias, and is returned as a pointer, such as *User. null is
+// decoded as nil.
+//
+// Returns an error wrapping ErrUnknownType if no type is registered at the
+// path.
+func (r *Registry) UnmarshalEnvelope(data []byte) (any, error) {
+	if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		return nil, nil
+	}
+	var env Envelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return nil, err
+	}
+	o, err := r.Lookup(env.Type)
+	if err != nil {
+		return nil, err
+	}
+	v := o.New()
+	if v == nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownType, env.Type)
+	}
+	if len(env.Data) > 0 {
+		if err := json.Unmarshal(env.Data, v); err != nil {
+			return nil, fmt.Errorf("pobj: decoding %s: %w", env.Type, err)
+		}
+	}
+	return v, nil
+}
+
+// Any holds a value of any type registered in the DefaultRegistry, and is
+// encoded in JSON as an envelope (see MarshalEnvelope). It allows fields of
+// interface type to round-trip through JSON:
+//
+//	type Job struct {
+//		ID      string
+//		Payload pobj.Any
+//	}
+//
+//	job := &Job{ID: "1", Payload: pobj.Any{Value: &Welcome{Email: "a@b.c"}}}
+//
+// After decoding, Value holds a pointer to the value, such as *Welcome.
+type Any struct {
+	Value any
+}
+
+// MarshalJSON returns the envelope of a.Value.
+func (a Any) MarshalJSON() ([]byte, error) {
+	return MarshalEnvelope(a.Value)
+}
+
+// UnmarshalJSON decodes an envelope into a.Value.
+func (a *Any) UnmarshalJSON(data []byte) error {
+	v, err := UnmarshalEnvelope(data)
+	if err != nil {
+		return err
+	}
+	a.Value = v
+	return nil
+}
@@ -0,0 +1,96 @@
+package pobj_test
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/KarpelesLab/pobj"
+)
+
+type envAdmin struct {
+	ID    string `json:"id"`
+	Level int    `json:"level"`
+}
+
+type envGuest struct {
+	Name string `json:"name"`
+}
+
+func TestEnvelope(t *testing.T) {
+	r := pobj.NewRegistry()
+	pobj.RegisterIn[envAdmin](r, "user/admin").Alias("admin")
+	pobj.RegisterIn[envGuest](r, "user/guest")
+
+	for _, v := range []any{&envAdmin{ID: "1", Level: 3}, envAdmin{ID: "1", Level: 3}} {
+		data, err := r.MarshalEnvelope(v)
+		if err != nil {
+			t.Fatalf("MarshalEnvelope failed: %v", err)
+		}
+		if want := `{"type":"user/admin","data":{"id":"1","level":3}}`; string(data) != want {
+			t.Errorf("wrong envelope, got %s, want %s", data, want)
+		}
+		res, err := r.UnmarshalEnvelope(data)
+		if err != nil || !reflect.DeepEqual(res, &envAdmin{ID: "1", Level: 3}) {
+			t.Errorf("UnmarshalEnvelope failed, got %#v, %v", res, err)
+		}
+	}
+
+	// aliases are accepted, and values are allocated with New
+	res, err := r.UnmarshalEnvelope([]byte(`{"type":"admin","data":{"id":"2"}}`))
+	if err != nil || !reflect.DeepEqual(res, &envAdmin{ID: "2"}) {
+		t.Errorf("UnmarshalEnvelope with alias failed, got %#v, %v", res, err)
+	}
+	if res, err := r.UnmarshalEnvelope([]byte(`null`)); res != nil || err != nil {
+		t.Errorf("wrong result for null, got %v, %v", res, err)
+	}
+	if data, err := r.MarshalEnvelope((*envAdmin)(nil)); err != nil || string(data) != "null" {
+		t.Errorf("wrong envelope for a nil pointer, got %s, %v", data, err)
+	}
+
+	if _, err := r.MarshalEnvelope(&TestPerson{}); !errors.Is(err, pobj.ErrUnknownType) {
+		t.Errorf("wrong error for unregistered type, got %v", err)
+	}
+	for _, data := range []string{`{"type":"nope","data":{}}`, `{"type":"user","data":{}}`} {
+		if _, err := r.UnmarshalEnvelope([]byte(data)); !errors.Is(err, pobj.ErrUnknownType) {
+			t.Errorf("wrong error for %s, got %v", data, err)
+		}
+	}
+	if _, err := r.UnmarshalEnvelope([]byte(`{"type":"user/guest","data":{"name":1}}`)); err == nil {
+		t.Error("expected an error for bad data")
+	}
+}
+
+func TestAny(t *testing.T) {
+	pobj.Register[envAdmin]("envelope/admin")
+	pobj.Register[envGuest]("envelope/guest")
+
+	type queue struct {
+		Items []pobj.Any `json:"items"`
+		Last  pobj.Any   `json:"last"`
+	}
+	q := &queue{Items: []pobj.Any{{Value: &envAdmin{ID: "1"}}, {Value: envGuest{Name: "John"}}}, Last: pobj.Any{Value: (*envAdmin)(nil)}}
+	data, err := json.Marshal(q)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	want := `{"items":[{"type":"envelope/admin","data":{"id":"1","level":0}},` +
+		`{"type":"envelope/guest","data":{"name":"John"}}],"last":null}`
+	if string(data) != want {
+		t.Errorf("wrong encoding, got %s, want %s", data, want)
+	}
+
+	var res queue
+	if err := json.Unmarshal(data, &res); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	wantQ := queue{Items: []pobj.Any{{Value: &envAdmin{ID: "1"}}, {Value: &envGuest{Name: "John"}}}}
+	if !reflect.DeepEqual(res, wantQ) {
+		t.Errorf("wrong decoding, got %#v", res)
+	}
+
+	if _, err := json.Marshal(pobj.Any{Value: 42}); !errors.Is(err, pobj.ErrUnknownType) {
+		t.Errorf("wrong error for unregistered type, got %v", err)
+	}
+}
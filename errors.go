@@ -1,6 +1,10 @@
 package pobj
 
-import "errors"
+import (
+	"errors"
+	"reflect"
+	"strings"
+)
 
 // Sentinel errors for common failure cases.
 var (
@@ -14,4 +18,144 @@ var (
 	// has no associated ObjectActions or when the specific action being used
 	// is nil within the ObjectActions.
 	ErrMissingAction = errors.New("pobj: no such action exists")
+
+	// ErrUnknownMethod is returned when trying to call a method that hasn't
+	// been registered.
+	ErrUnknownMethod = errors.New("pobj: unknown method")
+
+	// ErrMissingInstance is returned when calling a method that requires an
+	// instance without providing one.
+	ErrMissingInstance = errors.New("pobj: method requires an instance")
+
+	// ErrInvalidPath is returned when an object path does not follow the
+	// syntax described in ParsePath.
+	ErrInvalidPath = errors.New("pobj: invalid path")
+
+	// ErrInvalidQuery is returned when a ListQuery filters or sorts on a
+	// field that is not allowed for the object, or is otherwise invalid.
+	ErrInvalidQuery = errors.New("pobj: invalid list query")
+
+	// ErrDuplicatePath is returned when registering a type at a path that
+	// already has a registered type.
+	ErrDuplicatePath = errors.New("pobj: path already registered")
+
+	// ErrInvalidMethodName is returned when a method name is not in the
+	// "object/path:methodName" format.
+	ErrInvalidMethodName = errors.New("pobj: invalid method name")
+
+	// ErrNotCallable is returned when the value passed as a method is not a
+	// function that can be converted to a typutil.Callable.
+	ErrNotCallable = errors.New("pobj: method is not callable")
+
+	// ErrForbidden is returned when calling an action or method requiring a
+	// permission the caller does not have, as decided by the registry's
+	// Authorizer (see Object.Require).
+	ErrForbidden = errors.New("pobj: forbidden")
+
+	// ErrInvalidTag is returned when registering a struct type with a pobj
+	// field tag that cannot be parsed, or does not apply to the field type.
+	ErrInvalidTag = errors.New("pobj: invalid struct tag")
+
+	// ErrValidation is wrapped by the *ValidationError returned when an
+	// instance does not satisfy the pobj tags of its fields.
+	ErrValidation = errors.New("pobj: validation failed")
+
+	// ErrTypeIDConflict is returned when loading type IDs that conflict with
+	// the IDs already assigned (see LoadTypeIDs).
+	ErrTypeIDConflict = errors.New("pobj: type ID conflict")
 )
+
+// RegistrationError describes a failed registration. It wraps one of the
+// sentinel errors above, which can be tested with errors.Is.
+type RegistrationError struct {
+	Path     string       // Path (or "path:method" name) being registered
+	Type     reflect.Type // Type being registered, or the method's type
+	Existing reflect.Type // Type already registered at Path, if any
+	Err      error        // Underlying sentinel error
+}
+
+// Error returns a human readable description of the error.
+func (e *RegistrationError) Error() string {
+	var b strings.Builder
+	b.WriteString(e.Err.Error())
+	if e.Path != "" {
+		b.WriteString(": ")
+		b.WriteString(e.Path)
+	}
+	if e.Type != nil {
+		b.WriteString(" (")
+		b.WriteString(e.Type.String())
+		b.WriteString(")")
+	}
+	if e.Existing != nil {
+		b.WriteString(", existing type ")
+		b.WriteString(e.Existing.String())
+	}
+	return b.String()
+}
+
+// Unwrap returns the underlying sentinel error.
+func (e *RegistrationError) Unwrap() error {
+	return e.Err
+}
+
+// TypeError is returned by the generic helpers (ById, List, Create, ...) when
+// an action returns a value of an unexpected type.
+type TypeError struct {
+	Action   string       // Name of the action, such as "Fetch"
+	Expected reflect.Type // Type the action should have returned
+	Got      reflect.Type // Type the action returned, nil if it returned nil
+}
+
+// Error returns a human readable description of the error.
+func (e *TypeError) Error() string {
+	got := "<nil>"
+	if e.Got != nil {
+		got = e.Got.String()
+	}
+	return "pobj: bad type returned by " + e.Action + ", should have returned a " + e.Expected.String() + " but returned a " + got
+}
+
+// ValidationError is returned by Object.Validate, and by the Create and
+// Update actions, when fields of an instance do not satisfy the options of
+// their pobj tag. It wraps ErrValidation.
+type ValidationError struct {
+	Path   string        // Path of the object
+	Fields []*FieldError // Invalid fields, in declaration order
+}
+
+// Error returns a human readable description of the error, listing all the
+// invalid fields.
+func (e *ValidationError) Error() string {
+	var b strings.Builder
+	b.WriteString(ErrValidation.Error())
+	if e.Path != "" {
+		b.WriteString(": ")
+		b.WriteString(e.Path)
+	}
+	for i, f := range e.Fields {
+		if i == 0 {
+			b.WriteString(": ")
+		} else {
+			b.WriteString(", ")
+		}
+		b.WriteString(f.Error())
+	}
+	return b.String()
+}
+
+// Unwrap returns ErrValidation.
+func (e *ValidationError) Unwrap() error {
+	return ErrValidation
+}
+
+// FieldError describes an invalid field of a ValidationError.
+type FieldError struct {
+	Field   string // Go name of the field
+	Message string // Why the value is invalid, such as "is required"
+}
+
+// Error returns the field name followed by the message.
+func (e *FieldError) Error() string {
+	return e.Field + " " + e.Message
+}
@@ -1,12 +1,13 @@
 module github.com/KarpelesLab/pobj
 
-go 1.22.0
+go 1.23.0
 
-toolchain go1.23.0
-
-require github.com/KarpelesLab/typutil v0.2.19
+require (
+	github.com/KarpelesLab/typutil v0.2.19
+	github.com/fxamacker/cbor/v2 v2.9.2
+)
 
 require (
 	github.com/KarpelesLab/pjson v0.1.9 // indirect
-	golang.org/x/exp v0.0.0-20240909161429-701f63a606c0 // indirect
+	github.com/x448/float16 v0.8.4 // indirect
 )
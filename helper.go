@@ -2,7 +2,9 @@ package pobj
 
 import (
 	"context"
-	"fmt"
+	"reflect"
+
+	"github.com/KarpelesLab/typutil"
 )
 
 // ById fetches an object instance by its ID using the object's Fetch action.
@@ -19,17 +21,17 @@ import (
 //   - No Action or Fetch action is registered
 //   - The Fetch action fails
 func (o *Object) ById(ctx context.Context, id string) (any, error) {
-	if o.Action == nil {
-		return nil, ErrMissingAction
-	}
-	get := o.Action.Fetch
-	if get == nil {
-		return nil, ErrMissingAction
-	}
-	if get.IsStringArg(0) {
-		return get.CallArg(ctx, id)
+	get, err := o.action(func(a *ObjectActions) *typutil.Callable { return a.Fetch })
+	if err != nil {
+		return nil, err
 	}
-	return get.CallArg(ctx, struct{ Id string }{Id: id})
+	inv := &Invocation{Object: o, Action: "Fetch", ID: id}
+	return inv.invoke(ctx, func(ctx context.Context, inv *Invocation) (any, error) {
+		if get.IsStringArg(0) {
+			return get.CallArg(ctx, inv.ID)
+		}
+		return get.CallArg(ctx, struct{ Id string }{Id: inv.ID})
+	})
 }
 
 // ById is a generic helper that fetches a typed object by its ID.
@@ -48,7 +50,12 @@ func (o *Object) ById(ctx context.Context, id string) (any, error) {
 //   - The Fetch action fails
 //   - The returned object is not of the expected type
 func ById[T any](ctx context.Context, id string) (*T, error) {
-	o := GetByType[T]()
+	return ByIdIn[T](ctx, DefaultRegistry, id)
+}
+
+// ByIdIn is like ById but looks up the type T in registry r.
+func ByIdIn[T any](ctx context.Context, r *Registry, id string) (*T, error) {
+	o := GetByTypeIn[T](r)
 	if o == nil {
 		return nil, ErrUnknownType
 	}
@@ -56,9 +63,275 @@ func ById[T any](ctx context.Context, id string) (*T, error) {
 	if err != nil {
 		return nil, err
 	}
+	return asType[T]("Fetch", res)
+}
+
+// asType converts the result of action to *T, or returns a *TypeError.
+func asType[T any](action string, res any) (*T, error) {
 	res_final, ok := res.(*T)
 	if !ok {
-		return nil, fmt.Errorf("pobj: bad type returned by Fetch, should have returned a %T but returned a %T", (*T)(nil), res)
+		return nil, &TypeError{Action: action, Expected: reflect.TypeOf((*T)(nil)), Got: reflect.TypeOf(res)}
+	}
+	return res_final, nil
+}
+
+// action returns the named action callable of o, or ErrMissingAction.
+func (o *Object) action(get func(*ObjectActions) *typutil.Callable) (*typutil.Callable, error) {
+	act := o.Actions()
+	if act == nil {
+		return nil, ErrMissingAction
+	}
+	c := get(act)
+	if c == nil {
+		return nil, ErrMissingAction
+	}
+	return c, nil
+}
+
+// Update replaces the object identified by id with data using the object's
+// Update action. Like ById, the Update action may either take the id as a
+// string followed by the data, or a single struct with Id and Data fields.
+//
+// data is validated first, see Validate.
+//
+// Returns the result of the Update action, or ErrMissingAction if the object
+// has no Update action.
+func (o *Object) Update(ctx context.Context, id string, data any) (any, error) {
+	upd, err := o.action(func(a *ObjectActions) *typutil.Callable { return a.Update })
+	if err != nil {
+		return nil, err
+	}
+	inv := &Invocation{Object: o, Action: "Update", ID: id, Args: []any{data}}
+	return inv.invoke(ctx, validated(idDataCall(upd)))
+}
+
+// Patch partially updates the object identified by id using the object's
+// Patch action. The patch is typically a map of field names to values. The
+// arguments are passed the same way as for Update.
+//
+// Returns the result of the Patch action, or ErrMissingAction if the object
+// has no Patch action.
+func (o *Object) Patch(ctx context.Context, id string, patch any) (any, error) {
+	p, err := o.action(func(a *ObjectActions) *typutil.Callable { return a.Patch })
+	if err != nil {
+		return nil, err
+	}
+	inv := &Invocation{Object: o, Action: "Patch", ID: id, Args: []any{patch}}
+	return inv.invoke(ctx, idDataCall(p))
+}
+
+// idDataCall returns an Invoker calling c with the ID and data of the
+// invocation, either as two arguments if c takes a string first, or as a
+// struct with Id and Data fields.
+func idDataCall(c *typutil.Callable) Invoker {
+	return func(ctx context.Context, inv *Invocation) (any, error) {
+		var data any
+		if len(inv.Args) > 0 {
+			data = inv.Args[0]
+		}
+		if c.IsStringArg(0) {
+			return c.CallArg(ctx, inv.ID, data)
+		}
+		return c.CallArg(ctx, struct {
+			Id   string
+			Data any
+		}{Id: inv.ID, Data: data})
+	}
+}
+
+// Delete removes the object identified by id using the object's Delete
+// action. Like ById, the Delete action may either take the id as a string or
+// a struct with an Id field.
+//
+// Returns ErrMissingAction if the object has no Delete action.
+func (o *Object) Delete(ctx context.Context, id string) error {
+	del, err := o.action(func(a *ObjectActions) *typutil.Callable { return a.Delete })
+	if err != nil {
+		return err
+	}
+	inv := &Invocation{Object: o, Action: "Delete", ID: id}
+	_, err = inv.invoke(ctx, func(ctx context.Context, inv *Invocation) (any, error) {
+		if del.IsStringArg(0) {
+			return del.CallArg(ctx, inv.ID)
+		}
+		return del.CallArg(ctx, struct{ Id string }{Id: inv.ID})
+	})
+	return err
+}
+
+// Count returns the number of objects using the object's Count action. The
+// value returned by the action is converted to an int64.
+//
+// Returns ErrMissingAction if the object has no Count action.
+func (o *Object) Count(ctx context.Context) (int64, error) {
+	cnt, err := o.action(func(a *ObjectActions) *typutil.Callable { return a.Count })
+	if err != nil {
+		return 0, err
+	}
+	inv := &Invocation{Object: o, Action: "Count"}
+	res, err := inv.invoke(ctx, argsCall(cnt))
+	if err != nil {
+		return 0, err
+	}
+	return typutil.As[int64](res)
+}
+
+// List returns objects using the object's List action. The given arguments
+// are passed as is to the action, except for a single *ListQuery argument
+// which is checked against the object's filterable and sortable fields (see
+// CheckQuery) and only passed if the List action accepts an argument.
+//
+// Returns ErrMissingAction if the object has no List action.
+func (o *Object) List(ctx context.Context, args ...any) (any, error) {
+	list, err := o.action(func(a *ObjectActions) *typutil.Callable { return a.List })
+	if err != nil {
+		return nil, err
+	}
+	args, err = o.listArgs(list, args)
+	if err != nil {
+		return nil, err
+	}
+	inv := &Invocation{Object: o, Action: "List", Args: args}
+	return inv.invoke(ctx, argsCall(list))
+}
+
+// Create creates a new object from data using the object's Create action.
+// data is validated first, see Validate.
+//
+// Returns ErrMissingAction if the object has no Create action.
+func (o *Object) Create(ctx context.Context, data any) (any, error) {
+	create, err := o.action(func(a *ObjectActions) *typutil.Callable { return a.Create })
+	if err != nil {
+		return nil, err
+	}
+	inv := &Invocation{Object: o, Action: "Create", Args: []any{data}}
+	return inv.invoke(ctx, validated(argsCall(create)))
+}
+
+// Clear deletes all objects using the object's Clear action.
+//
+// Returns ErrMissingAction if the object has no Clear action.
+func (o *Object) Clear(ctx context.Context) error {
+	clr, err := o.action(func(a *ObjectActions) *typutil.Callable { return a.Clear })
+	if err != nil {
+		return err
+	}
+	inv := &Invocation{Object: o, Action: "Clear"}
+	_, err = inv.invoke(ctx, argsCall(clr))
+	return err
+}
+
+// argsCall returns an Invoker calling c with the arguments of the
+// invocation.
+func argsCall(c *typutil.Callable) Invoker {
+	return func(ctx context.Context, inv *Invocation) (any, error) {
+		return c.CallArg(ctx, inv.Args...)
+	}
+}
+
+// List is a generic helper that lists objects of type T using its List
+// action. The given arguments are passed as is to the action, which must
+// return a []*T.
+//
+// Returns ErrUnknownType if T is not registered, ErrMissingAction if it has
+// no List action, or a *TypeError if the action returns a value of another type.
+func List[T any](ctx context.Context, args ...any) ([]*T, error) {
+	return ListIn[T](ctx, DefaultRegistry, args...)
+}
+
+// ListIn is like List but looks up the type T in registry r.
+func ListIn[T any](ctx context.Context, r *Registry, args ...any) ([]*T, error) {
+	o := GetByTypeIn[T](r)
+	if o == nil {
+		return nil, ErrUnknownType
+	}
+	res, err := o.List(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+	res_final, ok := res.([]*T)
+	if !ok {
+		return nil, &TypeError{Action: "List", Expected: reflect.TypeOf(res_final), Got: reflect.TypeOf(res)}
 	}
 	return res_final, nil
 }
+
+// Create is a generic helper that creates an object of type T using its
+// Create action, and returns the typed result.
+//
+// Returns ErrUnknownType if T is not registered, ErrMissingAction if it has
+// no Create action, or a *TypeError if the action returns a value of another type.
+func Create[T any](ctx context.Context, data *T) (*T, error) {
+	return CreateIn[T](ctx, DefaultRegistry, data)
+}
+
+// CreateIn is like Create but looks up the type T in registry r.
+func CreateIn[T any](ctx context.Context, r *Registry, data *T) (*T, error) {
+	o := GetByTypeIn[T](r)
+	if o == nil {
+		return nil, ErrUnknownType
+	}
+	res, err := o.Create(ctx, data)
+	if err != nil {
+		return nil, err
+	}
+	return asType[T]("Create", res)
+}
+
+// Clear is a generic helper that deletes all objects of type T using its
+// Clear action.
+//
+// Returns ErrUnknownType if T is not registered, or ErrMissingAction if it has
+// no Clear action.
+func Clear[T any](ctx context.Context) error {
+	return ClearIn[T](ctx, DefaultRegistry)
+}
+
+// ClearIn is like Clear but looks up the type T in registry r.
+func ClearIn[T any](ctx context.Context, r *Registry) error {
+	o := GetByTypeIn[T](r)
+	if o == nil {
+		return ErrUnknownType
+	}
+	return o.Clear(ctx)
+}
+
+// Update is a generic helper that updates the object of type T identified by
+// id using its Update action, and returns the typed result.
+//
+// Returns ErrUnknownType if T is not registered, ErrMissingAction if it has
+// no Update action, or a *TypeError if the action returns a value of another type.
+func Update[T any](ctx context.Context, id string, data *T) (*T, error) {
+	return UpdateIn[T](ctx, DefaultRegistry, id, data)
+}
+
+// UpdateIn is like Update but looks up the type T in registry r.
+func UpdateIn[T any](ctx context.Context, r *Registry, id string, data *T) (*T, error) {
+	o := GetByTypeIn[T](r)
+	if o == nil {
+		return nil, ErrUnknownType
+	}
+	res, err := o.Update(ctx, id, data)
+	if err != nil {
+		return nil, err
+	}
+	return asType[T]("Update", res)
+}
+
+// Delete is a generic helper that deletes the object of type T identified by
+// id using its Delete action.
+//
+// Returns ErrUnknownType if T is not registered, or ErrMissingAction if it has
+// no Delete action.
+func Delete[T any](ctx context.Context, id string) error {
+	return DeleteIn[T](ctx, DefaultRegistry, id)
+}
+
+// DeleteIn is like Delete but looks up the type T in registry r.
+func DeleteIn[T any](ctx context.Context, r *Registry, id string) error {
+	o := GetByTypeIn[T](r)
+	if o == nil {
+		return ErrUnknownType
+	}
+	return o.Delete(ctx, id)
+}
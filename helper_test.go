@@ -2,9 +2,12 @@ package pobj_test
 
 import (
 	"context"
+	"errors"
+	"reflect"
 	"testing"
 
 	"github.com/KarpelesLab/pobj"
+	"github.com/KarpelesLab/typutil"
 )
 
 func TestById(t *testing.T) {
@@ -114,3 +117,177 @@ func TestObjectById(t *testing.T) {
 		}
 	})
 }
+
+func TestCrudActions(t *testing.T) {
+	r := pobj.NewRegistry()
+	ctx := context.Background()
+	deleted := ""
+
+	pobj.RegisterActionsIn[TestCompany](r, "company", &pobj.ObjectActions{
+		Update: typutil.Func(func(ctx context.Context, id string, data *TestCompany) (*TestCompany, error) {
+			data.ID = id
+			return data, nil
+		}),
+		Patch: typutil.Func(func(ctx context.Context, id string, patch map[string]any) (*TestCompany, error) {
+			name, _ := patch["Name"].(string)
+			return &TestCompany{ID: id, Name: name}, nil
+		}),
+		Delete: typutil.Func(func(ctx context.Context, id string) error {
+			deleted = id
+			return nil
+		}),
+		Count: typutil.Func(func(ctx context.Context) (int, error) {
+			return 42, nil
+		}),
+	})
+
+	// Struct argument form
+	pobj.RegisterActionsIn[TestPerson](r, "person", &pobj.ObjectActions{
+		Update: typutil.Func(func(ctx context.Context, args struct {
+			Id   string
+			Data *TestPerson
+		}) (*TestPerson, error) {
+			args.Data.ID = args.Id
+			return args.Data, nil
+		}),
+		Delete: typutil.Func(func(ctx context.Context, args struct{ Id string }) error {
+			deleted = args.Id
+			return nil
+		}),
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		c, err := pobj.UpdateIn[TestCompany](ctx, r, "c1", &TestCompany{Name: "Updated"})
+		if err != nil {
+			t.Fatalf("Update failed: %v", err)
+		}
+		if c.ID != "c1" || c.Name != "Updated" {
+			t.Errorf("Wrong result: %+v", c)
+		}
+
+		p, err := pobj.UpdateIn[TestPerson](ctx, r, "p1", &TestPerson{Name: "Struct"})
+		if err != nil {
+			t.Fatalf("Update with struct argument failed: %v", err)
+		}
+		if p.ID != "p1" || p.Name != "Struct" {
+			t.Errorf("Wrong result: %+v", p)
+		}
+	})
+
+	t.Run("Patch", func(t *testing.T) {
+		res, err := r.Get("company").Patch(ctx, "c2", map[string]any{"Name": "Patched"})
+		if err != nil {
+			t.Fatalf("Patch failed: %v", err)
+		}
+		if c, ok := res.(*TestCompany); !ok || c.ID != "c2" || c.Name != "Patched" {
+			t.Errorf("Wrong result: %+v", res)
+		}
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		if err := pobj.DeleteIn[TestCompany](ctx, r, "c3"); err != nil {
+			t.Fatalf("Delete failed: %v", err)
+		}
+		if deleted != "c3" {
+			t.Errorf("Wrong deleted id, got %s, want c3", deleted)
+		}
+		if err := pobj.DeleteIn[TestPerson](ctx, r, "p3"); err != nil {
+			t.Fatalf("Delete with struct argument failed: %v", err)
+		}
+		if deleted != "p3" {
+			t.Errorf("Wrong deleted id, got %s, want p3", deleted)
+		}
+	})
+
+	t.Run("Count", func(t *testing.T) {
+		n, err := r.Get("company").Count(ctx)
+		if err != nil {
+			t.Fatalf("Count failed: %v", err)
+		}
+		if n != 42 {
+			t.Errorf("Wrong count, got %d, want 42", n)
+		}
+	})
+
+	t.Run("Missing actions", func(t *testing.T) {
+		person := r.Get("person")
+		if _, err := person.Patch(ctx, "x", nil); err != pobj.ErrMissingAction {
+			t.Errorf("Wrong error, got %v, want %v", err, pobj.ErrMissingAction)
+		}
+		if _, err := person.Count(ctx); err != pobj.ErrMissingAction {
+			t.Errorf("Wrong error, got %v, want %v", err, pobj.ErrMissingAction)
+		}
+		if err := pobj.DeleteIn[struct{}](ctx, r, "x"); err != pobj.ErrUnknownType {
+			t.Errorf("Wrong error, got %v, want %v", err, pobj.ErrUnknownType)
+		}
+	})
+}
+
+func TestListCreateClear(t *testing.T) {
+	r := pobj.NewRegistry()
+	ctx := context.Background()
+	cleared := false
+
+	pobj.RegisterActionsIn[TestCompany](r, "company", &pobj.ObjectActions{
+		List: typutil.Func(func(ctx context.Context) ([]*TestCompany, error) {
+			return []*TestCompany{{ID: "1"}, {ID: "2"}}, nil
+		}),
+		Create: typutil.Func(func(ctx context.Context, data *TestCompany) (*TestCompany, error) {
+			data.ID = "new"
+			return data, nil
+		}),
+		Clear: typutil.Func(func(ctx context.Context) error {
+			cleared = true
+			return nil
+		}),
+	})
+	pobj.RegisterActionsIn[TestPerson](r, "person", &pobj.ObjectActions{
+		List: typutil.Func(func(ctx context.Context) ([]string, error) {
+			return []string{"wrong"}, nil
+		}),
+	})
+
+	list, err := pobj.ListIn[TestCompany](ctx, r)
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(list) != 2 || list[1].ID != "2" {
+		t.Errorf("Wrong list: %v", list)
+	}
+
+	c, err := pobj.CreateIn[TestCompany](ctx, r, &TestCompany{Name: "Created"})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if c.ID != "new" || c.Name != "Created" {
+		t.Errorf("Wrong result: %+v", c)
+	}
+
+	if err := pobj.ClearIn[TestCompany](ctx, r); err != nil {
+		t.Fatalf("Clear failed: %v", err)
+	}
+	if !cleared {
+		t.Error("Clear action was not called")
+	}
+
+	// Wrong result type
+	_, err = pobj.ListIn[TestPerson](ctx, r)
+	var typErr *pobj.TypeError
+	if !errors.As(err, &typErr) {
+		t.Fatalf("Expected *TypeError, got %v", err)
+	}
+	if typErr.Action != "List" || typErr.Got != reflect.TypeOf([]string{}) {
+		t.Errorf("Wrong TypeError: %+v", typErr)
+	}
+
+	// Missing actions
+	if _, err := pobj.CreateIn[TestPerson](ctx, r, &TestPerson{}); err != pobj.ErrMissingAction {
+		t.Errorf("Wrong error, got %v, want %v", err, pobj.ErrMissingAction)
+	}
+	if err := pobj.ClearIn[TestPerson](ctx, r); err != pobj.ErrMissingAction {
+		t.Errorf("Wrong error, got %v, want %v", err, pobj.ErrMissingAction)
+	}
+	if _, err := pobj.ListIn[struct{}](ctx, r); err != pobj.ErrUnknownType {
+		t.Errorf("Wrong error, got %v, want %v", err, pobj.ErrUnknownType)
+	}
+}
@@ -0,0 +1,173 @@
+package pobj
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+var ctxTyp = reflect.TypeOf((*context.Context)(nil)).Elem()
+
+// ImportMethods registers the exported methods of the object's type (using
+// the method set of *T, which includes value receiver methods) as methods of
+// this object requiring an instance. The Go method name is used as the
+// method name, and methods already registered under that name are kept.
+//
+// The receiver is taken from the context when the method is called (see
+// Method.Invoke and WithInstance), so a Go method such as:
+//
+//	func (u *User) Rename(ctx context.Context, name string) error
+//
+// becomes callable as "user:Rename" with a single string argument. Methods
+// without an error result get one, returning ErrMissingInstance when no
+// instance of the object's type is available.
+//
+// If filter is not nil, only the methods for which it returns true are
+// imported. Returns a *RegistrationError wrapping ErrUnknownType if the
+// object has no type, or ErrNotCallable if a method cannot be converted, in
+// which case no method is imported.
+func (o *Object) ImportMethods(filter func(reflect.Method) bool) error {
+	if o == nil {
+		return ErrUnknownType
+	}
+	typ := o.rtype()
+	if typ == nil {
+		return &RegistrationError{Path: o.String(), Err: ErrUnknownType}
+	}
+	ptr := reflect.PointerTo(typ)
+
+	methods := make(map[string]*Method)
+	for i := 0; i < ptr.NumMethod(); i++ {
+		rm := ptr.Method(i)
+		if filter != nil && !filter(rm) {
+			continue
+		}
+		fn, err := instanceFunc(typ, rm)
+		if err != nil {
+			return &RegistrationError{Path: o.String() + ":" + rm.Name, Type: rm.Type, Err: err}
+		}
+		callable, err := toCallable(fn.Interface())
+		if err != nil {
+			return &RegistrationError{Path: o.String() + ":" + rm.Name, Type: rm.Type, Err: err}
+		}
+		m := &Method{
+			callable: callable,
+			fnType:   fn.Type(),
+			object:   o,
+			name:     rm.Name,
+		}
+		m.requiresInstance.Store(true)
+		methods[rm.Name] = m
+	}
+
+	o.reg.mu.Lock()
+	defer o.reg.mu.Unlock()
+	for name, m := range methods {
+		if _, ok := o.methods.get(name); ok {
+			continue
+		}
+		o.methods.set(name, m)
+	}
+	return nil
+}
+
+// instanceFunc returns a func calling method rm of *typ on the instance found
+// in its context argument. A context argument is added if the method has none,
+// and an error result, for a missing instance, if it has none.
+func instanceFunc(typ reflect.Type, rm reflect.Method) (reflect.Value, error) {
+	mt := rm.Type // includes the receiver as first argument
+
+	ctxPos := -1
+	in := make([]reflect.Type, 0, mt.NumIn())
+	for i := 1; i < mt.NumIn(); i++ {
+		if mt.In(i).Implements(ctxTyp) {
+			if ctxPos != -1 {
+				return reflect.Value{}, ErrNotCallable
+			}
+			ctxPos = i - 1
+		}
+		in = append(in, mt.In(i))
+	}
+	addCtx := ctxPos == -1
+	if addCtx {
+		in = append([]reflect.Type{ctxTyp}, in...)
+		ctxPos = 0
+	}
+	out := make([]reflect.Type, mt.NumOut())
+	addErr := true
+	for i := range out {
+		out[i] = mt.Out(i)
+		if out[i] == errTyp {
+			addErr = false
+		}
+	}
+	if addErr {
+		// the instance may be missing, which must be reported as an error
+		out = append(out, errTyp)
+	}
+
+	ft := reflect.FuncOf(in, out, mt.IsVariadic())
+	fn := reflect.MakeFunc(ft, func(args []reflect.Value) []reflect.Value {
+		ctx, _ := args[ctxPos].Interface().(context.Context)
+		recv, err := instanceValue(ctx, typ)
+		if err != nil {
+			return errorResults(ft, err)
+		}
+		if addCtx {
+			args = args[1:]
+		}
+		callArgs := append([]reflect.Value{recv}, args...)
+		var res []reflect.Value
+		if mt.IsVariadic() {
+			res = rm.Func.CallSlice(callArgs)
+		} else {
+			res = rm.Func.Call(callArgs)
+		}
+		if addErr {
+			res = append(res, reflect.Zero(errTyp))
+		}
+		return res
+	})
+	return fn, nil
+}
+
+// instanceValue returns the instance stored in ctx as a *typ.
+func instanceValue(ctx context.Context, typ reflect.Type) (reflect.Value, error) {
+	var v any
+	if ctx != nil {
+		v = ctx.Value(instanceKey{})
+	}
+	if v == nil {
+		return reflect.Value{}, ErrMissingInstance
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Type() {
+	case reflect.PointerTo(typ):
+		if rv.IsNil() {
+			return reflect.Value{}, ErrMissingInstance
+		}
+		return rv, nil
+	case typ:
+		p := reflect.New(typ)
+		p.Elem().Set(rv)
+		return p, nil
+	}
+	return reflect.Value{}, fmt.Errorf("%w: got a %s, expected a %s", ErrMissingInstance, rv.Type(), reflect.PointerTo(typ))
+}
+
+// errorResults returns zero values for the results of ft, with err set as
+// the first error result. ft must have an error result.
+func errorResults(ft reflect.Type, err error) []reflect.Value {
+	res := make([]reflect.Value, ft.NumOut())
+	found := false
+	for i := range res {
+		res[i] = reflect.Zero(ft.Out(i))
+		if !found && ft.Out(i) == errTyp {
+			res[i] = reflect.ValueOf(&err).Elem()
+			found = true
+		}
+	}
+	return res
+}
+
+var errTyp = reflect.TypeOf((*error)(nil)).Elem()
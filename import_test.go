@@ -0,0 +1,114 @@
+package pobj_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/KarpelesLab/pobj"
+)
+
+// importUser is used to test ImportMethods
+type importUser struct {
+	Name string
+}
+
+func (u *importUser) Rename(ctx context.Context, name string) error {
+	u.Name = name
+	return nil
+}
+
+func (u importUser) Greeting(prefix string) string {
+	return prefix + " " + u.Name
+}
+
+func (u *importUser) Join(sep string, parts []string) (string, error) {
+	return u.Name + sep + strings.Join(parts, sep), nil
+}
+
+func TestImportMethods(t *testing.T) {
+	r := pobj.NewRegistry()
+	ctx := context.Background()
+	obj := pobj.RegisterIn[importUser](r, "user")
+
+	explicit := r.RegisterMethod("user:Join", func() string { return "explicit" })
+
+	if err := obj.ImportMethods(nil); err != nil {
+		t.Fatalf("ImportMethods failed: %v", err)
+	}
+
+	rename := obj.Method("Rename")
+	if rename == nil || !rename.RequiresInstance() {
+		t.Fatal("Rename was not imported as an instance method")
+	}
+	u := &importUser{Name: "John"}
+	if _, err := rename.Invoke(ctx, u, "Jane"); err != nil {
+		t.Fatalf("Invoke failed: %v", err)
+	}
+	if u.Name != "Jane" {
+		t.Errorf("Method was not called on the instance, name is %s", u.Name)
+	}
+
+	res, err := obj.Method("Greeting").Invoke(ctx, u, "Hello")
+	if err != nil || res != "Hello Jane" {
+		t.Errorf("Invoke of value method failed, got %v, %v", res, err)
+	}
+
+	if obj.Method("Join") != explicit {
+		t.Error("Explicitly registered method was overwritten")
+	}
+
+	if _, err := rename.Invoke(ctx, nil, "x"); err != pobj.ErrMissingInstance {
+		t.Errorf("Wrong error, got %v, want %v", err, pobj.ErrMissingInstance)
+	}
+
+	// Filter
+	obj2 := pobj.RegisterIn[importUser](r, "user2")
+	err = obj2.ImportMethods(func(m reflect.Method) bool { return m.Name == "Join" })
+	if err != nil {
+		t.Fatalf("ImportMethods failed: %v", err)
+	}
+	if !reflect.DeepEqual(obj2.Methods(), []string{"Join"}) {
+		t.Errorf("Wrong methods imported, got %v", obj2.Methods())
+	}
+	res, err = obj2.Method("Join").Invoke(ctx, u, "-", []string{"a", "b"})
+	if err != nil || res != "Jane-a-b" {
+		t.Errorf("Invoke failed, got %v, %v", res, err)
+	}
+
+	if err := r.Root().ImportMethods(nil); err == nil {
+		t.Error("ImportMethods on an object without type should fail")
+	}
+}
+
+func TestImportMethodsWithoutError(t *testing.T) {
+	r := pobj.NewRegistry()
+	ctx := context.Background()
+	obj := pobj.RegisterIn[importUser](r, "user")
+	if err := obj.ImportMethods(nil); err != nil {
+		t.Fatalf("ImportMethods failed: %v", err)
+	}
+
+	// Greeting has no error result, one is added for the instance
+	ft := obj.Method("Greeting").Type()
+	if ft.NumOut() != 2 || ft.Out(1).String() != "error" {
+		t.Errorf("wrong func type, got %s", ft)
+	}
+	res, err := obj.Static("Greeting").CallArg(pobj.WithInstance(ctx, importUser{Name: "John"}), "Hi")
+	if err != nil || res != "Hi John" {
+		t.Errorf("call failed, got %v, %v", res, err)
+	}
+
+	calls := []context.Context{
+		ctx,
+		pobj.WithInstance(ctx, (*importUser)(nil)),
+		pobj.WithInstance(ctx, &TestPerson{}),
+	}
+	for i, c := range calls {
+		if _, err := obj.Static("Greeting").CallArg(c, "Hi"); !errors.Is(err, pobj.ErrMissingInstance) {
+			t.Errorf("call %d: wrong error, got %v", i, err)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package pobj
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+// instanceKey is the context key used to store the object instance.
+type instanceKey struct{}
+
+// WithInstance returns a copy of ctx carrying instance as the object instance
+// for methods that require one (see Method.SetRequiresInstance).
+func WithInstance(ctx context.Context, instance any) context.Context {
+	return context.WithValue(ctx, instanceKey{}, instance)
+}
+
+// InstanceFrom returns the object instance stored in ctx by WithInstance, if
+// it is a T or a *T.
+func InstanceFrom[T any](ctx context.Context) (*T, bool) {
+	switch v := ctx.Value(instanceKey{}).(type) {
+	case *T:
+		return v, v != nil
+	case T:
+		return &v, true
+	}
+	return nil, false
+}
+
+// Invoke calls the method with the given arguments.
+//
+// If the method requires an instance, the instance is made available to the
+// method through the context (see InstanceFrom). The instance can be passed
+// directly, as a string ID which is resolved with the object's Fetch action
+// (see Object.ById) once the interceptors have run and the permissions of the
+// method are checked, or as nil in which case the instance already stored in
+// ctx is used. An error wrapping ErrMissingInstance is returned if no
+// instance is available, if the ID is not found, or if the instance is not a
+// T or a *T of the object's type.
+//
+// Methods that do not require an instance are called with the instance in
+// context if one is given, which must also be of the object's type. The call
+// goes through the interceptors of the method and its object (see
+// Interceptor).
+func (m *Method) Invoke(ctx context.Context, instance any, args ...any) (any, error) {
+	if m == nil {
+		return nil, ErrUnknownMethod
+	}
+	if instance == nil && m.RequiresInstance() {
+		instance = ctx.Value(instanceKey{})
+	}
+	inv := &Invocation{Object: m.object, Method: m, Args: args}
+	if id, ok := instance.(string); ok && m.RequiresInstance() {
+		// resolved once the permissions of the method are checked
+		inv.ID = id
+	} else {
+		if isNil(instance) {
+			instance = nil
+		}
+		if err := m.checkInstance(instance); err != nil {
+			return nil, err
+		}
+		inv.Instance = instance
+	}
+	return inv.invoke(ctx, func(ctx context.Context, inv *Invocation) (any, error) {
+		if inv.Instance == nil && inv.ID != "" && m.RequiresInstance() {
+			res, err := m.object.ById(ctx, inv.ID)
+			if err != nil {
+				return nil, err
+			}
+			if isNil(res) {
+				return nil, fmt.Errorf("%w: %s %q not found", ErrMissingInstance, m.object, inv.ID)
+			}
+			inv.Instance = res
+		}
+		if err := m.checkInstance(inv.Instance); err != nil {
+			return nil, err
+		}
+		if inv.Instance != nil {
+			ctx = WithInstance(ctx, inv.Instance)
+		}
+		return m.callable.CallArg(ctx, inv.Args...)
+	})
+}
+
+// checkInstance returns ErrMissingInstance if the method requires an
+// instance and instance is nil, or an error wrapping it if instance is not a
+// T or a *T of the object's type. Objects without a type accept any instance.
+func (m *Method) checkInstance(instance any) error {
+	if isNil(instance) {
+		if m.RequiresInstance() {
+			return ErrMissingInstance
+		}
+		return nil
+	}
+	typ := m.object.rtype()
+	if typ == nil {
+		return nil
+	}
+	if t := reflect.TypeOf(instance); t != typ && t != reflect.PointerTo(typ) {
+		return fmt.Errorf("%w: got a %s, expected a %s", ErrMissingInstance, t, reflect.PointerTo(typ))
+	}
+	return nil
+}
+
+// isNil reports whether v is nil or a nil pointer, map, slice or
+// interface.
+func isNil(v any) bool {
+	if v == nil {
+		return true
+	}
+	switch rv := reflect.ValueOf(v); rv.Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Slice, reflect.Interface:
+		return rv.IsNil()
+	}
+	return false
+}
@@ -0,0 +1,103 @@
+package pobj_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/KarpelesLab/pobj"
+	"github.com/KarpelesLab/typutil"
+)
+
+func TestInstanceContext(t *testing.T) {
+	ctx := pobj.WithInstance(context.Background(), &TestPerson{Name: "ctx"})
+	p, ok := pobj.InstanceFrom[TestPerson](ctx)
+	if !ok || p.Name != "ctx" {
+		t.Errorf("InstanceFrom failed, got %v %v", p, ok)
+	}
+
+	ctx = pobj.WithInstance(context.Background(), TestPerson{Name: "value"})
+	p, ok = pobj.InstanceFrom[TestPerson](ctx)
+	if !ok || p.Name != "value" {
+		t.Errorf("InstanceFrom with value failed, got %v %v", p, ok)
+	}
+
+	if _, ok := pobj.InstanceFrom[TestCompany](ctx); ok {
+		t.Error("InstanceFrom should fail for another type")
+	}
+	if _, ok := pobj.InstanceFrom[TestPerson](context.Background()); ok {
+		t.Error("InstanceFrom should fail without instance")
+	}
+}
+
+func TestMethodInvoke(t *testing.T) {
+	r := pobj.NewRegistry()
+	ctx := context.Background()
+
+	pobj.RegisterActionsIn[TestPerson](r, "person", &pobj.ObjectActions{
+		Fetch: typutil.Func(func(ctx context.Context, id string) (*TestPerson, error) {
+			if id == "missing" {
+				return nil, nil
+			}
+			return &TestPerson{ID: id, Name: "fetched"}, nil
+		}),
+	})
+	greet := r.RegisterMethod("person:greet", func(ctx context.Context, greeting string) (string, error) {
+		p, ok := pobj.InstanceFrom[TestPerson](ctx)
+		if !ok {
+			return "", errors.New("no instance")
+		}
+		return greeting + " " + p.Name, nil
+	}).SetRequiresInstance(true)
+	static := r.RegisterMethod("person:hello", func(ctx context.Context) string {
+		return "hello"
+	})
+
+	res, err := greet.Invoke(ctx, &TestPerson{Name: "John"}, "Hi")
+	if err != nil || res != "Hi John" {
+		t.Errorf("Invoke with instance failed, got %v, %v", res, err)
+	}
+
+	res, err = greet.Invoke(ctx, "p-1", "Hello")
+	if err != nil || res != "Hello fetched" {
+		t.Errorf("Invoke with ID failed, got %v, %v", res, err)
+	}
+
+	res, err = greet.Invoke(pobj.WithInstance(ctx, &TestPerson{Name: "Ctx"}), nil, "Hey")
+	if err != nil || res != "Hey Ctx" {
+		t.Errorf("Invoke with instance in context failed, got %v, %v", res, err)
+	}
+
+	if _, err := greet.Invoke(ctx, nil, "Hi"); err != pobj.ErrMissingInstance {
+		t.Errorf("Wrong error, got %v, want %v", err, pobj.ErrMissingInstance)
+	}
+
+	// not found, nil and wrong instances are rejected before the call
+	bad := []any{"missing", (*TestPerson)(nil), &TestCompany{}, 42}
+	for _, instance := range bad {
+		if _, err := greet.Invoke(ctx, instance, "Hi"); !errors.Is(err, pobj.ErrMissingInstance) {
+			t.Errorf("wrong error for %#v, got %v", instance, err)
+		}
+	}
+	if _, err := greet.Invoke(pobj.WithInstance(ctx, &TestCompany{}), nil, "Hi"); !errors.Is(err, pobj.ErrMissingInstance) {
+		t.Errorf("wrong error for an instance of another type in context, got %v", err)
+	}
+	if _, err := static.Invoke(ctx, &TestCompany{}); !errors.Is(err, pobj.ErrMissingInstance) {
+		t.Errorf("wrong error for a static method with another type, got %v", err)
+	}
+
+	res, err = static.Invoke(ctx, nil)
+	if err != nil || res != "hello" {
+		t.Errorf("Invoke of static method failed, got %v, %v", res, err)
+	}
+
+	if got := greet.ArgTypes(); len(got) != 1 || got[0] != reflect.TypeOf("") {
+		t.Errorf("Wrong argument types, got %v", got)
+	}
+
+	var nilMethod *pobj.Method
+	if _, err := nilMethod.Invoke(ctx, nil); err != pobj.ErrUnknownMethod {
+		t.Errorf("Wrong error, got %v, want %v", err, pobj.ErrUnknownMethod)
+	}
+}
@@ -0,0 +1,141 @@
+package pobj
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// Invocation describes a call to an action or a method, as seen by
+// interceptors. Interceptors may modify ID, Instance and Args before calling
+// the next handler.
+type Invocation struct {
+	Object   *Object // Object the action or method belongs to
+	Action   string  // Name of the action, such as "Fetch", or "" for a method
+	Method   *Method // Method being called, or nil for an action
+	ID       string  // ID of the object, for Fetch, Update, Patch and Delete, and methods called with an ID
+	Instance any     // Instance of the object, for methods called with one, nil until an ID is resolved
+	Args     []any   // Data of Create, Update and Patch, arguments of List and methods
+}
+
+// Name returns the name of the action or method being called.
+func (inv *Invocation) Name() string {
+	if inv.Method != nil {
+		return inv.Method.Name()
+	}
+	return inv.Action
+}
+
+// String returns the object path followed by the action or method name,
+// such as "user:Fetch" or "user:getByEmail".
+func (inv *Invocation) String() string {
+	return inv.Object.String() + ":" + inv.Name()
+}
+
+// Invoker performs an invocation, either by calling the next interceptor or
+// the underlying callable.
+type Invoker func(ctx context.Context, inv *Invocation) (any, error)
+
+// Interceptor wraps the invocations of actions and methods, for logging,
+// authorization, metrics, retries and so on. It must call next to proceed
+// with the invocation, and may inspect or replace its result:
+//
+//	func logCalls(ctx context.Context, inv *pobj.Invocation, next pobj.Invoker) (any, error) {
+//		start := time.Now()
+//		res, err := next(ctx, inv)
+//		log.Printf("%s took %s, err=%v", inv, time.Since(start), err)
+//		return res, err
+//	}
+//
+// Interceptors are registered for a whole registry with Registry.Use, for an
+// object and its descendants with Object.Use, or for a single method with
+// Method.Use. They run in that order, from the outermost to the innermost:
+// registry, then objects from the top of the hierarchy down to the object
+// being called, then method. Interceptors registered at the same level run
+// in registration order.
+//
+// Interceptors apply to all the calls made by pobj, that is the actions
+// called through Object (ById, List, Create, ...) and the generic helpers,
+// and the methods called with Method.Invoke, including by pobjhttp and
+// pobjrpc. Callables obtained with Object.Actions or Method.Callable and
+// called directly bypass them.
+type Interceptor func(ctx context.Context, inv *Invocation, next Invoker) (any, error)
+
+// Use adds interceptors to all the actions and methods of the
+// DefaultRegistry. See Registry.Use.
+func Use(interceptors ...Interceptor) {
+	DefaultRegistry.Use(interceptors...)
+}
+
+// Use adds interceptors to all the actions and methods of this registry.
+// They run before the interceptors of objects and methods.
+func (r *Registry) Use(interceptors ...Interceptor) {
+	r.root.Use(interceptors...)
+}
+
+// Use adds interceptors to the actions and methods of this object and all
+// its descendants, and returns the object for chaining. Descendants are
+// found through the primary path of objects, not through aliases. An object
+// without type keeps its interceptors when its children are unregistered.
+func (o *Object) Use(interceptors ...Interceptor) *Object {
+	if o == nil {
+		return nil
+	}
+	o.reg.mu.Lock()
+	defer o.reg.mu.Unlock()
+	appendInterceptors(&o.interceptors, interceptors)
+	return o
+}
+
+// Use adds interceptors to this method and returns the method for chaining.
+// They run after the interceptors of the object and its ancestors.
+func (m *Method) Use(interceptors ...Interceptor) *Method {
+	if m == nil {
+		return nil
+	}
+	m.object.reg.mu.Lock()
+	defer m.object.reg.mu.Unlock()
+	appendInterceptors(&m.interceptors, interceptors)
+	return m
+}
+
+// appendInterceptors publishes a copy of *p with interceptors appended.
+// Caller must hold the registry lock.
+func appendInterceptors(p *atomic.Pointer[[]Interceptor], interceptors []Interceptor) {
+	var cur []Interceptor
+	if l := p.Load(); l != nil {
+		cur = *l
+	}
+	n := appendCopy(cur, interceptors)
+	p.Store(&n)
+}
+
+// invoke performs inv through the interceptors of its object, its ancestors
+// and its method, then checks its permissions and calls call.
+func (inv *Invocation) invoke(ctx context.Context, call Invoker) (any, error) {
+	callable := call
+	call = func(ctx context.Context, inv *Invocation) (any, error) {
+		if err := inv.authorize(ctx); err != nil {
+			return nil, err
+		}
+		return callable(ctx, inv)
+	}
+
+	var chain []Interceptor
+	for o := inv.Object; o != nil; o = o.parent {
+		if l := o.interceptors.Load(); l != nil {
+			chain = append(append([]Interceptor(nil), *l...), chain...)
+		}
+	}
+	if inv.Method != nil {
+		if l := inv.Method.interceptors.Load(); l != nil {
+			chain = append(chain, *l...)
+		}
+	}
+	for i := len(chain) - 1; i >= 0; i-- {
+		next, icpt := call, chain[i]
+		call = func(ctx context.Context, inv *Invocation) (any, error) {
+			return icpt(ctx, inv, next)
+		}
+	}
+	return call(ctx, inv)
+}
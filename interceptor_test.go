@@ -0,0 +1,127 @@
+package pobj_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/KarpelesLab/pobj"
+	"github.com/KarpelesLab/typutil"
+)
+
+// recorder returns an interceptor appending its name and the invocation to
+// calls.
+func recorder(name string, calls *[]string) pobj.Interceptor {
+	return func(ctx context.Context, inv *pobj.Invocation, next pobj.Invoker) (any, error) {
+		*calls = append(*calls, name+" "+inv.String())
+		return next(ctx, inv)
+	}
+}
+
+func TestInterceptors(t *testing.T) {
+	r := pobj.NewRegistry()
+	ctx := context.Background()
+	var calls []string
+
+	obj := pobj.RegisterActionsIn[TestPerson](r, "admin/person", &pobj.ObjectActions{
+		Fetch: typutil.Func(func(ctx context.Context, id string) (*TestPerson, error) {
+			return &TestPerson{ID: id}, nil
+		}),
+		Create: typutil.Func(func(ctx context.Context, p *TestPerson) (*TestPerson, error) {
+			return p, nil
+		}),
+	})
+	hello := r.RegisterMethod("admin/person:hello", func(ctx context.Context, name string) string {
+		p, _ := pobj.InstanceFrom[TestPerson](ctx)
+		return "hello " + name + " from " + p.ID
+	}).SetRequiresInstance(true)
+	pobj.RegisterIn[TestCompany](r, "company")
+
+	r.Use(recorder("global", &calls))
+	r.Get("admin").Use(recorder("admin", &calls))
+	obj.Use(recorder("person", &calls))
+	hello.Use(recorder("method", &calls))
+
+	if _, err := obj.ById(ctx, "p1"); err != nil {
+		t.Fatalf("ById failed: %v", err)
+	}
+	want := []string{"global admin/person:Fetch", "admin admin/person:Fetch", "person admin/person:Fetch"}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("wrong interceptor order, got %v, want %v", calls, want)
+	}
+
+	calls = nil
+	res, err := hello.Invoke(ctx, "p2", "john")
+	if err != nil || res != "hello john from p2" {
+		t.Fatalf("Invoke failed, got %v, %v", res, err)
+	}
+	want = []string{
+		"global admin/person:hello", "admin admin/person:hello", "person admin/person:hello", "method admin/person:hello",
+		// instance resolved with the Fetch action within the method call
+		"global admin/person:Fetch", "admin admin/person:Fetch", "person admin/person:Fetch",
+	}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("wrong interceptor order, got %v, want %v", calls, want)
+	}
+
+	// other subtrees only get the global interceptors
+	calls = nil
+	if _, err := r.Get("company").Count(ctx); !errors.Is(err, pobj.ErrMissingAction) {
+		t.Errorf("wrong error, got %v", err)
+	}
+	if len(calls) != 0 {
+		t.Errorf("missing actions should not be intercepted, got %v", calls)
+	}
+}
+
+func TestInterceptorModify(t *testing.T) {
+	r := pobj.NewRegistry()
+	ctx := context.Background()
+	deny := errors.New("denied")
+
+	obj := pobj.RegisterActionsIn[TestPerson](r, "person", &pobj.ObjectActions{
+		Fetch: typutil.Func(func(ctx context.Context, args struct{ Id string }) (*TestPerson, error) {
+			return &TestPerson{ID: args.Id}, nil
+		}),
+		Update: typutil.Func(func(ctx context.Context, id string, p *TestPerson) (*TestPerson, error) {
+			p.ID = id
+			return p, nil
+		}),
+		Delete: typutil.Func(func(ctx context.Context, id string) error {
+			t.Error("Delete should not be called")
+			return nil
+		}),
+	})
+	add := r.RegisterMethod("person:add", func(a, b int) int { return a + b })
+
+	obj.Use(func(ctx context.Context, inv *pobj.Invocation, next pobj.Invoker) (any, error) {
+		switch inv.Action {
+		case "Fetch", "Update":
+			inv.ID = "x-" + inv.ID
+		case "Delete":
+			return nil, deny
+		}
+		if inv.Method != nil && inv.Name() == "add" {
+			inv.Args = []any{inv.Args[0], 10}
+		}
+		return next(ctx, inv)
+	})
+
+	if p, err := pobj.ByIdIn[TestPerson](ctx, r, "1"); err != nil || p.ID != "x-1" {
+		t.Errorf("ID not rewritten for Fetch, got %+v, %v", p, err)
+	}
+	if p, err := pobj.UpdateIn[TestPerson](ctx, r, "2", &TestPerson{Name: "John"}); err != nil || p.ID != "x-2" || p.Name != "John" {
+		t.Errorf("ID not rewritten for Update, got %+v, %v", p, err)
+	}
+	if err := pobj.DeleteIn[TestPerson](ctx, r, "3"); err != deny {
+		t.Errorf("wrong error, got %v, want %v", err, deny)
+	}
+	if res, err := add.Invoke(ctx, nil, 1, 2); err != nil || res != 11 {
+		t.Errorf("args not rewritten, got %v, %v", res, err)
+	}
+	// calling the callable directly bypasses interceptors
+	if res, err := typutil.Call[int](add.Callable(), ctx, 1, 2); err != nil || res != 3 {
+		t.Errorf("direct call failed, got %v, %v", res, err)
+	}
+}
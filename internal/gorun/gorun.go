@@ -0,0 +1,62 @@
+// Package gorun runs generated Go programs importing packages of the current
+// module. Commands use it to inspect the registries populated by the init
+// functions of these packages.
+package gorun
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// ImportPaths resolves package patterns (such as "." or "./models/...") to
+// import paths using "go list".
+func ImportPaths(patterns []string) ([]string, error) {
+	if len(patterns) == 0 {
+		patterns = []string{"."}
+	}
+	out, err := goCmd(append([]string{"list", "-f", "{{.ImportPath}}"}, patterns...)...)
+	if err != nil {
+		return nil, err
+	}
+	return strings.Fields(string(out)), nil
+}
+
+// Run writes a main package with the given imports and main function body
+// in a temporary directory of the current module, runs it with "go run" and
+// returns its standard output. Imports are import specs, such as
+// `"encoding/json"` or `_ "example.com/app/models"`.
+func Run(imports []string, body string) ([]byte, error) {
+	dir, err := os.MkdirTemp(".", "_pobj-run-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	var src bytes.Buffer
+	src.WriteString("// Code generated by pobj. DO NOT EDIT.\n\npackage main\n\nimport (\n")
+	for _, imp := range imports {
+		fmt.Fprintf(&src, "\t%s\n", imp)
+	}
+	fmt.Fprintf(&src, ")\n\nfunc main() {\n%s\n}\n", body)
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), src.Bytes(), 0644); err != nil {
+		return nil, err
+	}
+	return goCmd("run", "./"+filepath.ToSlash(dir))
+}
+
+// goCmd runs the go command with the given arguments and returns its
+// standard output. Standard error is included in the returned error.
+func goCmd(args ...string) ([]byte, error) {
+	cmd := exec.Command("go", args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("go %s: %w\n%s", args[0], err, stderr.Bytes())
+	}
+	return out, nil
+}
@@ -0,0 +1,245 @@
+package pobj
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/KarpelesLab/typutil"
+)
+
+// FilterOp is a comparison operator used in a Filter.
+type FilterOp string
+
+// Filter operators supported in a ListQuery.
+const (
+	OpEq       FilterOp = "eq"       // field equals value
+	OpNe       FilterOp = "ne"       // field differs from value
+	OpLt       FilterOp = "lt"       // field is lower than value
+	OpLte      FilterOp = "lte"      // field is lower than or equal to value
+	OpGt       FilterOp = "gt"       // field is greater than value
+	OpGte      FilterOp = "gte"      // field is greater than or equal to value
+	OpIn       FilterOp = "in"       // field equals one of the values in value (a slice)
+	OpPrefix   FilterOp = "prefix"   // field starts with value
+	OpContains FilterOp = "contains" // field contains value
+)
+
+// valid returns true if op is one of the known operators.
+func (op FilterOp) valid() bool {
+	switch op {
+	case OpEq, OpNe, OpLt, OpLte, OpGt, OpGte, OpIn, OpPrefix, OpContains:
+		return true
+	}
+	return false
+}
+
+// Filter is a filter expression of a ListQuery, such as Name eq "John".
+type Filter struct {
+	Field string   `json:"field"`
+	Op    FilterOp `json:"op"`
+	Value any      `json:"value"`
+}
+
+// SortField describes a sort key of a ListQuery.
+type SortField struct {
+	Field string `json:"field"`
+	Desc  bool   `json:"desc,omitempty"`
+}
+
+// ListQuery is the standard argument of List actions, describing which page
+// of results to return and how to filter and sort them.
+//
+// Either Cursor (as returned in ListResult.NextCursor) or Offset can be used
+// for pagination. A Limit of zero lets the List action pick a default.
+type ListQuery struct {
+	Cursor string      `json:"cursor,omitempty"`
+	Offset int         `json:"offset,omitempty"`
+	Limit  int         `json:"limit,omitempty"`
+	Sort   []SortField `json:"sort,omitempty"`
+	Filter []Filter    `json:"filter,omitempty"`
+}
+
+// ListResult is the standard result of List actions accepting a ListQuery.
+type ListResult[T any] struct {
+	Items      []*T   `json:"items"`
+	NextCursor string `json:"next_cursor,omitempty"` // Cursor of the next page, empty if this is the last page
+	Total      int64  `json:"total"`                 // Total number of matching objects, or -1 if unknown
+}
+
+// SetFilterable marks this field as usable in ListQuery filters and returns
+// the field for chaining.
+func (f *Field) SetFilterable(filterable bool) *Field {
+	if f == nil {
+		return nil
+	}
+	f.filterable.Store(filterable)
+	return f
+}
+
+// Filterable returns true if this field can be used in ListQuery filters.
+func (f *Field) Filterable() bool {
+	if f == nil {
+		return false
+	}
+	return f.filterable.Load()
+}
+
+// SetSortable marks this field as usable to sort ListQuery results and
+// returns the field for chaining.
+func (f *Field) SetSortable(sortable bool) *Field {
+	if f == nil {
+		return nil
+	}
+	f.sortable.Store(sortable)
+	return f
+}
+
+// Sortable returns true if this field can be used to sort ListQuery results.
+func (f *Field) Sortable() bool {
+	if f == nil {
+		return false
+	}
+	return f.sortable.Load()
+}
+
+// SetFilterable marks the given fields as usable in ListQuery filters and
+// returns the object for chaining. Field metadata is created as needed.
+func (o *Object) SetFilterable(fields ...string) *Object {
+	if o == nil {
+		return nil
+	}
+	for _, name := range fields {
+		o.field(name).SetFilterable(true)
+	}
+	return o
+}
+
+// SetSortable marks the given fields as usable to sort ListQuery results and
+// returns the object for chaining. Field metadata is created as needed.
+func (o *Object) SetSortable(fields ...string) *Object {
+	if o == nil {
+		return nil
+	}
+	for _, name := range fields {
+		o.field(name).SetSortable(true)
+	}
+	return o
+}
+
+// FilterFields returns the sorted names of the fields that can be used in
+// ListQuery filters.
+func (o *Object) FilterFields() []string {
+	return o.fieldsWith((*Field).Filterable)
+}
+
+// SortFields returns the sorted names of the fields that can be used to sort
+// ListQuery results.
+func (o *Object) SortFields() []string {
+	return o.fieldsWith((*Field).Sortable)
+}
+
+// fieldsWith returns the sorted names of the fields matching fn.
+func (o *Object) fieldsWith(fn func(*Field) bool) []string {
+	if o == nil {
+		return nil
+	}
+	var res []string
+	for name, f := range o.fields.load() {
+		if fn(f) {
+			res = append(res, name)
+		}
+	}
+	sort.Strings(res)
+	return res
+}
+
+// CheckQuery verifies that q only filters and sorts on fields allowed for
+// this object, using known operators. Errors wrap ErrInvalidQuery.
+func (o *Object) CheckQuery(q *ListQuery) error {
+	if q == nil {
+		return nil
+	}
+	if q.Limit < 0 || q.Offset < 0 {
+		return fmt.Errorf("%w: negative limit or offset", ErrInvalidQuery)
+	}
+	for _, f := range q.Filter {
+		if !o.Field(f.Field).Filterable() {
+			return fmt.Errorf("%w: field %q is not filterable", ErrInvalidQuery, f.Field)
+		}
+		if !f.Op.valid() {
+			return fmt.Errorf("%w: unknown filter operator %q", ErrInvalidQuery, f.Op)
+		}
+	}
+	for _, s := range q.Sort {
+		if !o.Field(s.Field).Sortable() {
+			return fmt.Errorf("%w: field %q is not sortable", ErrInvalidQuery, s.Field)
+		}
+	}
+	return nil
+}
+
+// listArgs returns the arguments to pass to the List action list. If args is
+// a single ListQuery, it is checked and only passed if the action accepts an
+// argument. An empty query can be used with a List action taking no argument.
+func (o *Object) listArgs(list *typutil.Callable, args []any) ([]any, error) {
+	if len(args) != 1 {
+		return args, nil
+	}
+	var q *ListQuery
+	switch v := args[0].(type) {
+	case *ListQuery:
+		q = v
+	case ListQuery:
+		q = &v
+	default:
+		return args, nil
+	}
+	if err := o.CheckQuery(q); err != nil {
+		return nil, err
+	}
+	switch list.ArgKind(0) {
+	case reflect.Struct, reflect.Pointer:
+		if q == nil {
+			q = &ListQuery{}
+		}
+		return []any{q}, nil
+	}
+	if q != nil && (q.Cursor != "" || q.Offset != 0 || q.Limit != 0 || len(q.Sort) > 0 || len(q.Filter) > 0) {
+		return nil, fmt.Errorf("%w: List action of %s does not accept a query", ErrInvalidQuery, o)
+	}
+	return nil, nil
+}
+
+// ListPage is a generic helper that lists objects of type T using its List
+// action and the given query. The List action may return a []*T, a
+// ListResult[T] or a *ListResult[T]. A plain slice is returned with a Total
+// of -1.
+//
+// Returns ErrUnknownType if T is not registered, ErrMissingAction if it has no
+// List action, an error wrapping ErrInvalidQuery if the query uses fields that
+// are not allowed, or a *TypeError if the action returns a value of another type.
+func ListPage[T any](ctx context.Context, q *ListQuery) (*ListResult[T], error) {
+	return ListPageIn[T](ctx, DefaultRegistry, q)
+}
+
+// ListPageIn is like ListPage but looks up the type T in registry r.
+func ListPageIn[T any](ctx context.Context, r *Registry, q *ListQuery) (*ListResult[T], error) {
+	o := GetByTypeIn[T](r)
+	if o == nil {
+		return nil, ErrUnknownType
+	}
+	res, err := o.List(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	switch v := res.(type) {
+	case *ListResult[T]:
+		return v, nil
+	case ListResult[T]:
+		return &v, nil
+	case []*T:
+		return &ListResult[T]{Items: v, Total: -1}, nil
+	}
+	return nil, &TypeError{Action: "List", Expected: reflect.TypeOf((*ListResult[T])(nil)), Got: reflect.TypeOf(res)}
+}
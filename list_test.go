@@ -0,0 +1,85 @@
+package pobj_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/KarpelesLab/pobj"
+	"github.com/KarpelesLab/typutil"
+)
+
+func TestListQuery(t *testing.T) {
+	r := pobj.NewRegistry()
+	ctx := context.Background()
+	var got *pobj.ListQuery
+
+	pobj.RegisterActionsIn[TestCompany](r, "company", &pobj.ObjectActions{
+		List: typutil.Func(func(ctx context.Context, q *pobj.ListQuery) (*pobj.ListResult[TestCompany], error) {
+			got = q
+			return &pobj.ListResult[TestCompany]{
+				Items:      []*TestCompany{{ID: "1"}},
+				NextCursor: "next",
+				Total:      10,
+			}, nil
+		}),
+	}).SetFilterable("Name", "Address").SetSortable("Name")
+
+	pobj.RegisterActionsIn[TestPerson](r, "person", &pobj.ObjectActions{
+		List: typutil.Func(func(ctx context.Context) ([]*TestPerson, error) {
+			return []*TestPerson{{ID: "p"}}, nil
+		}),
+	})
+
+	company := r.Get("company")
+	if !reflect.DeepEqual(company.FilterFields(), []string{"Address", "Name"}) {
+		t.Errorf("Wrong filter fields, got %v", company.FilterFields())
+	}
+	if !reflect.DeepEqual(company.SortFields(), []string{"Name"}) {
+		t.Errorf("Wrong sort fields, got %v", company.SortFields())
+	}
+	if company.Field("Name").Type() != reflect.TypeOf("") {
+		t.Errorf("Wrong field type, got %v", company.Field("Name").Type())
+	}
+
+	q := &pobj.ListQuery{
+		Limit:  5,
+		Sort:   []pobj.SortField{{Field: "Name", Desc: true}},
+		Filter: []pobj.Filter{{Field: "Name", Op: pobj.OpPrefix, Value: "Acme"}},
+	}
+	res, err := pobj.ListPageIn[TestCompany](ctx, r, q)
+	if err != nil {
+		t.Fatalf("ListPage failed: %v", err)
+	}
+	if got == nil || got.Limit != 5 || len(got.Filter) != 1 {
+		t.Errorf("Query was not passed to the List action, got %+v", got)
+	}
+	if len(res.Items) != 1 || res.NextCursor != "next" || res.Total != 10 {
+		t.Errorf("Wrong result: %+v", res)
+	}
+
+	// Fields not advertised are rejected
+	for _, bad := range []*pobj.ListQuery{
+		{Filter: []pobj.Filter{{Field: "ID", Op: pobj.OpEq, Value: "x"}}},
+		{Filter: []pobj.Filter{{Field: "Name", Op: "like", Value: "x"}}},
+		{Sort: []pobj.SortField{{Field: "Address"}}},
+		{Limit: -1},
+	} {
+		if _, err := pobj.ListPageIn[TestCompany](ctx, r, bad); !errors.Is(err, pobj.ErrInvalidQuery) {
+			t.Errorf("Wrong error for %+v, got %v, want %v", bad, err, pobj.ErrInvalidQuery)
+		}
+	}
+
+	// List actions without argument can be used with an empty query
+	pres, err := pobj.ListPageIn[TestPerson](ctx, r, &pobj.ListQuery{})
+	if err != nil {
+		t.Fatalf("ListPage failed: %v", err)
+	}
+	if len(pres.Items) != 1 || pres.Total != -1 {
+		t.Errorf("Wrong result: %+v", pres)
+	}
+	if _, err := pobj.ListPageIn[TestPerson](ctx, r, &pobj.ListQuery{Limit: 10}); !errors.Is(err, pobj.ErrInvalidQuery) {
+		t.Errorf("Wrong error, got %v, want %v", err, pobj.ErrInvalidQuery)
+	}
+}
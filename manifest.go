@@ -0,0 +1,239 @@
+package pobj
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+)
+
+// ManifestVersion is the version of the manifest format produced by
+// Registry.Manifest.
+const ManifestVersion = 1
+
+// manifestSchemaPrefix is the prefix of $ref values in manifest schemas.
+const manifestSchemaPrefix = "#/schemas/"
+
+// Manifest is a serializable description of a registry, for tools that
+// cannot import the packages populating it, such as code generators and
+// compatibility checks. Its JSON encoding is stable: objects are sorted by
+// path, methods by name, and fields are in declaration order.
+type Manifest struct {
+	Version int               `json:"version"`
+	Objects []*ObjectManifest `json:"objects"`
+
+	// Schemas holds the JSON Schemas of the registered types, keyed by
+	// object path, and of the other named struct types they use, keyed by
+	// type name. Schemas reference each other with "#/schemas/name".
+	Schemas map[string]*Schema `json:"schemas,omitempty"`
+}
+
+// ObjectManifest describes an object of a registry. Objects without type
+// are only included if they have methods.
+type ObjectManifest struct {
+	Path    string            `json:"path"`
+	Type    string            `json:"type,omitempty"`   // Go type name, such as "example.com/app/models.User"
+	TypeID  uint64            `json:"typeId,omitempty"` // ID of the type in binary encodings, see Object.TypeID
+	Doc     string            `json:"doc,omitempty"`
+	Aliases []string          `json:"aliases,omitempty"`
+	Actions []string          `json:"actions,omitempty"` // Names of the available actions, such as "Fetch"
+	Fields  []*FieldManifest  `json:"fields,omitempty"`
+	Methods []*MethodManifest `json:"methods,omitempty"`
+}
+
+// FieldManifest describes a field of a struct type, as encoded in JSON.
+type FieldManifest struct {
+	Name       string `json:"name"` // Go field name
+	JSON       string `json:"json"` // Name of the field in JSON
+	Type       string `json:"type"` // Go type, such as "[]string"
+	Doc        string `json:"doc,omitempty"`
+	Required   bool   `json:"required,omitempty"`
+	ReadOnly   bool   `json:"readonly,omitempty"`
+	Filterable bool   `json:"filterable,omitempty"`
+	Sortable   bool   `json:"sortable,omitempty"`
+}
+
+// MethodManifest describes a method of an object.
+type MethodManifest struct {
+	Name             string          `json:"name"`
+	Doc              string          `json:"doc,omitempty"`
+	Args             []*TypeManifest `json:"args,omitempty"`    // Arguments, excluding context.Context
+	Results          []*TypeManifest `json:"results,omitempty"` // Results, including error
+	Variadic         bool            `json:"variadic,omitempty"`
+	RequiresInstance bool            `json:"requiresInstance,omitempty"`
+}
+
+// TypeManifest describes the type of a method argument or result.
+type TypeManifest struct {
+	Go     string  `json:"go"`               // Go type, such as "*models.User"
+	Schema *Schema `json:"schema,omitempty"` // JSON Schema, nil for error
+}
+
+// actionNames lists the actions of ObjectActions in manifest order.
+var actionNames = []struct {
+	name string
+	get  func(*ObjectActions) bool
+}{
+	{"Fetch", func(a *ObjectActions) bool { return a.Fetch != nil }},
+	{"List", func(a *ObjectActions) bool { return a.List != nil }},
+	{"Count", func(a *ObjectActions) bool { return a.Count != nil }},
+	{"Create", func(a *ObjectActions) bool { return a.Create != nil }},
+	{"Update", func(a *ObjectActions) bool { return a.Update != nil }},
+	{"Patch", func(a *ObjectActions) bool { return a.Patch != nil }},
+	{"Delete", func(a *ObjectActions) bool { return a.Delete != nil }},
+	{"Clear", func(a *ObjectActions) bool { return a.Clear != nil }},
+}
+
+// Manifest returns a manifest describing the objects of r. Objects with a
+// type and no type ID yet are assigned one, in path order, so the manifest
+// records the IDs of all types (see LoadTypeIDs).
+func (r *Registry) Manifest() *Manifest {
+	b := r.NewSchemaBuilder(manifestSchemaPrefix)
+	m := &Manifest{Version: ManifestVersion, Objects: []*ObjectManifest{}}
+
+	for o := range r.root.DepthFirst() {
+		if om := o.manifest(b); om != nil {
+			m.Objects = append(m.Objects, om)
+		}
+	}
+	// depth-first order differs from path order, as in "a/b" and "a-b"
+	sort.Slice(m.Objects, func(i, j int) bool { return m.Objects[i].Path < m.Objects[j].Path })
+	r.assignTypeIDs(m)
+
+	m.Schemas = b.Defs()
+	return m
+}
+
+// manifest returns the description of o, or nil if o has no type and no
+// methods.
+func (o *Object) manifest(b *SchemaBuilder) *ObjectManifest {
+	typ := o.rtype()
+	methods := o.Methods()
+	if typ == nil && len(methods) == 0 {
+		return nil
+	}
+	om := &ObjectManifest{
+		Path:    o.String(),
+		Doc:     o.Doc(),
+		Aliases: o.Aliases(),
+	}
+	if typ != nil {
+		om.Type = typeName(typ)
+		if act := o.Actions(); act != nil {
+			for _, a := range actionNames {
+				if a.get(act) {
+					om.Actions = append(om.Actions, a.name)
+				}
+			}
+		}
+		if typ.Kind() == reflect.Struct {
+			for _, f := range jsonFields(typ) {
+				meta := o.Field(f.Name)
+				if meta.Hidden() {
+					continue
+				}
+				om.Fields = append(om.Fields, &FieldManifest{
+					Name:       f.Name,
+					JSON:       f.jsonName,
+					Type:       f.Type.String(),
+					Doc:        meta.Doc(),
+					Required:   f.required() || meta.Required(),
+					ReadOnly:   meta.ReadOnly(),
+					Filterable: meta.Filterable(),
+					Sortable:   meta.Sortable(),
+				})
+			}
+		}
+	}
+
+	for _, name := range methods {
+		meth := o.Method(name)
+		mm := &MethodManifest{
+			Name:             name,
+			Doc:              meth.Doc(),
+			RequiresInstance: meth.RequiresInstance(),
+		}
+		for _, a := range meth.ArgTypes() {
+			mm.Args = append(mm.Args, &TypeManifest{Go: a.String(), Schema: b.Schema(a)})
+		}
+		if ft := meth.Type(); ft != nil {
+			mm.Variadic = ft.IsVariadic()
+			for i := 0; i < ft.NumOut(); i++ {
+				out := ft.Out(i)
+				tm := &TypeManifest{Go: out.String()}
+				if out != errTyp {
+					tm.Schema = b.Schema(out)
+				}
+				mm.Results = append(mm.Results, tm)
+			}
+		}
+		om.Methods = append(om.Methods, mm)
+	}
+	return om
+}
+
+// typeName returns the fully qualified name of typ, such as
+// "example.com/app/models.User", or its Go syntax if it is not named.
+func typeName(typ reflect.Type) string {
+	if typ.Name() == "" || typ.PkgPath() == "" {
+		return typ.String()
+	}
+	return typ.PkgPath() + "." + typ.Name()
+}
+
+// LoadManifest reads a manifest in JSON format from r, as produced by
+// encoding the result of Registry.Manifest. Objects are sorted by path, as
+// Manifest.Object expects, so that edited or merged manifests can be read.
+// Returns an error if several objects have the same path.
+func LoadManifest(r io.Reader) (*Manifest, error) {
+	var m Manifest
+	if err := json.NewDecoder(r).Decode(&m); err != nil {
+		return nil, fmt.Errorf("pobj: reading manifest: %w", err)
+	}
+	if m.Version != ManifestVersion {
+		return nil, fmt.Errorf("pobj: unsupported manifest version %d", m.Version)
+	}
+	for _, om := range m.Objects {
+		if om == nil {
+			return nil, errors.New("pobj: reading manifest: null object")
+		}
+	}
+	sort.SliceStable(m.Objects, func(i, j int) bool { return m.Objects[i].Path < m.Objects[j].Path })
+	for i := 1; i < len(m.Objects); i++ {
+		if m.Objects[i].Path == m.Objects[i-1].Path {
+			return nil, fmt.Errorf("pobj: reading manifest: duplicate object %s", m.Objects[i].Path)
+		}
+	}
+	return &m, nil
+}
+
+// Object returns the description of the object at path, or nil.
+func (m *Manifest) Object(path string) *ObjectManifest {
+	i := sort.Search(len(m.Objects), func(i int) bool { return m.Objects[i].Path >= path })
+	if i < len(m.Objects) && m.Objects[i].Path == path {
+		return m.Objects[i]
+	}
+	return nil
+}
+
+// Method returns the description of the method with the given name, or nil.
+func (om *ObjectManifest) Method(name string) *MethodManifest {
+	for _, mm := range om.Methods {
+		if mm.Name == name {
+			return mm
+		}
+	}
+	return nil
+}
+
+// HasAction returns true if the object has the named action, such as "Fetch".
+func (om *ObjectManifest) HasAction(name string) bool {
+	for _, a := range om.Actions {
+		if a == name {
+			return true
+		}
+	}
+	return false
+}
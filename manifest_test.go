@@ -0,0 +1,136 @@
+package pobj_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/KarpelesLab/pobj"
+	"github.com/KarpelesLab/typutil"
+)
+
+type manifestUser struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Email string `json:"email,omitempty"`
+}
+
+func TestManifest(t *testing.T) {
+	r := pobj.NewRegistry()
+	obj := pobj.RegisterActionsIn[manifestUser](r, "shop/user", &pobj.ObjectActions{
+		Fetch: typutil.Func(func(ctx context.Context, id string) (*manifestUser, error) { return nil, nil }),
+		List:  typutil.Func(func(ctx context.Context) ([]*manifestUser, error) { return nil, nil }),
+	}).SetDoc("A user").Alias("legacy/user")
+	obj.SetFieldDoc("Name", "Full name")
+	obj.Field("Name").SetFilterable(true).SetSortable(true)
+	r.RegisterMethod("shop/user:rename", func(ctx context.Context, in *manifestUser, name string) (*manifestUser, error) {
+		return in, nil
+	}).SetDoc("Rename the user").SetRequiresInstance(true)
+	r.RegisterMethod("shop/user:count", func() int { return 0 })
+	r.RegisterMethod("tools:ping", func(ctx context.Context) error { return nil })
+
+	m := r.Manifest()
+	if m.Version != pobj.ManifestVersion {
+		t.Errorf("wrong version, got %d", m.Version)
+	}
+	var paths []string
+	for _, om := range m.Objects {
+		paths = append(paths, om.Path)
+	}
+	if want := []string{"shop/user", "tools"}; !reflect.DeepEqual(paths, want) {
+		t.Errorf("wrong objects, got %v, want %v", paths, want)
+	}
+
+	om := m.Object("shop/user")
+	if om == nil {
+		t.Fatal("shop/user not found")
+	}
+	if om.Type != "github.com/KarpelesLab/pobj_test.manifestUser" || om.Doc != "A user" {
+		t.Errorf("wrong object header, got %+v", om)
+	}
+	if !reflect.DeepEqual(om.Aliases, []string{"legacy/user"}) {
+		t.Errorf("wrong aliases, got %v", om.Aliases)
+	}
+	if !reflect.DeepEqual(om.Actions, []string{"Fetch", "List"}) || !om.HasAction("Fetch") || om.HasAction("Create") {
+		t.Errorf("wrong actions, got %v", om.Actions)
+	}
+	wantFields := []*pobj.FieldManifest{
+		{Name: "ID", JSON: "id", Type: "string", Required: true},
+		{Name: "Name", JSON: "name", Type: "string", Doc: "Full name", Required: true, Filterable: true, Sortable: true},
+		{Name: "Email", JSON: "email", Type: "string"},
+	}
+	if !reflect.DeepEqual(om.Fields, wantFields) {
+		got, _ := json.Marshal(om.Fields)
+		t.Errorf("wrong fields, got %s", got)
+	}
+
+	if len(om.Methods) != 2 || om.Methods[0].Name != "count" || om.Methods[1].Name != "rename" {
+		t.Fatalf("wrong methods, got %+v", om.Methods)
+	}
+	rename := om.Method("rename")
+	if rename.Doc != "Rename the user" || !rename.RequiresInstance {
+		t.Errorf("wrong method header, got %+v", rename)
+	}
+	if len(rename.Args) != 2 || rename.Args[0].Go != "*pobj_test.manifestUser" || rename.Args[1].Go != "string" {
+		t.Errorf("wrong args, got %+v", rename.Args)
+	}
+	if rename.Args[0].Schema.Ref != "#/schemas/shop~1user" {
+		t.Errorf("wrong arg schema, got %+v", rename.Args[0].Schema)
+	}
+	if len(rename.Results) != 2 || rename.Results[1].Go != "error" || rename.Results[1].Schema != nil {
+		t.Errorf("wrong results, got %+v", rename.Results)
+	}
+	if ping := m.Object("tools").Method("ping"); ping == nil || len(ping.Args) != 0 || len(ping.Results) != 1 {
+		t.Errorf("wrong ping method, got %+v", ping)
+	}
+	if s := m.Schemas["shop/user"]; s == nil || s.Properties["name"] == nil {
+		t.Errorf("wrong schemas, got %v", m.Schemas)
+	}
+	if m.Object("legacy/user") != nil || m.Object("nope") != nil {
+		t.Error("aliases and unknown paths should not be found")
+	}
+}
+
+func TestLoadManifest(t *testing.T) {
+	r := pobj.NewRegistry()
+	pobj.RegisterIn[manifestUser](r, "user").SetDoc("A user")
+	r.RegisterMethod("user:hello", func(name string) string { return "Hello " + name })
+
+	data, err := json.Marshal(r.Manifest())
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, _ := json.Marshal(r.Manifest())
+	if !bytes.Equal(data, again) {
+		t.Error("manifest encoding is not stable")
+	}
+
+	m, err := pobj.LoadManifest(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("LoadManifest failed: %v", err)
+	}
+	if !reflect.DeepEqual(m, r.Manifest()) {
+		t.Errorf("manifest did not round-trip, got %s", data)
+	}
+
+	if _, err := pobj.LoadManifest(strings.NewReader(`{"version":99}`)); err == nil {
+		t.Error("expected an error for an unsupported version")
+	}
+	// objects are sorted for lookups
+	m, err = pobj.LoadManifest(strings.NewReader(`{"version":1,"objects":[{"path":"user"},{"path":"company"}]}`))
+	if err != nil || m.Object("company") == nil || m.Object("user") == nil {
+		t.Errorf("lookup in unsorted manifest failed: %v", err)
+	}
+	if _, err := pobj.LoadManifest(strings.NewReader(`{"version":1,"objects":[{"path":"user"},{"path":"user"}]}`)); err == nil {
+		t.Error("expected an error for duplicate objects")
+	}
+	if _, err := pobj.LoadManifest(strings.NewReader(`{"version":1,"objects":[null]}`)); err == nil {
+		t.Error("expected an error for a null object")
+	}
+	if _, err := pobj.LoadManifest(strings.NewReader(`{`)); err == nil {
+		t.Error("expected an error for invalid JSON")
+	}
+}
@@ -5,42 +5,84 @@ package pobj
 
 import (
 	"reflect"
-	"strings"
-	"sync"
+	"regexp"
+	"sort"
+	"sync/atomic"
 
 	"github.com/KarpelesLab/typutil"
 )
 
 // Object represents a registered type in the object registry.
 // Objects can be organized hierarchically with parent/child relationships.
+//
+// All methods of Object are safe for concurrent use. Lookups (Child, Method,
+// Field, ...) are lock-free: the underlying maps are immutable snapshots that
+// are replaced as a whole when a registration changes them.
 type Object struct {
 	name     string                       // Name of the object in the registry
-	typ      reflect.Type                 // The Go type represented by this object
-	children map[string]*Object           // Child objects in the hierarchy (name → object)
+	state    atomic.Pointer[objectState]  // The registered type and actions
+	children cowMap[string, *Object]      // Child objects in the hierarchy (name → object)
 	static   map[string]*typutil.Callable // Static methods associated with this object (deprecated)
-	methods  map[string]*Method           // Methods associated with this object
-	fields   map[string]*Field            // Field metadata for struct types
-	Action   *ObjectActions               // Actions that can be performed on this object type
+	methods  cowMap[string, *Method]      // Methods associated with this object
+	fields   cowMap[string, *Field]       // Field metadata for struct types
 	parent   *Object                      // Parent object in the hierarchy
-	doc      string                       // Documentation for this object
+	reg      *Registry                    // Registry this object belongs to
+	aliases  cowMap[string, *Object]      // Alias paths of this object (path → parent holding the alias)
+	doc      atomic.Pointer[string]       // Documentation for this object
+
+	interceptors atomic.Pointer[[]Interceptor] // Interceptors of this object and its descendants
+	perms        cowMap[string, []string]      // Required permissions by action name, "" for all
+
+	// Action holds the actions given to the registration that gave this
+	// object its type. It is not changed by later registrations such as
+	// Replace, nor by Unregister. Since it is not synchronized, reads
+	// concurrent with the registration of an existing object without type,
+	// such as one holding only methods, are racy; use Actions for reads
+	// concurrent with registrations. A value assigned to it takes precedence
+	// over the registered actions, see Actions.
+	Action *ObjectActions
+
+	action0 *ObjectActions // Value Action was set to by the registration
+}
+
+// objectState holds the registration of an Object. It is never modified once
+// published, so readers always observe a consistent type and actions pair.
+type objectState struct {
+	typ     reflect.Type   // The Go type represented by this object
+	actions *ObjectActions // Actions that can be performed on this object type
 }
 
 // Field represents metadata about a struct field.
 type Field struct {
-	name   string       // Field name
-	doc    string       // Documentation for this field
-	typ    reflect.Type // Field type (from reflection)
-	object *Object      // The object this field belongs to
+	name       string                 // Field name
+	doc        atomic.Pointer[string] // Documentation for this field
+	typ        reflect.Type           // Field type (from reflection)
+	object     *Object                // The object this field belongs to
+	filterable atomic.Bool            // If true, List queries may filter on this field
+	sortable   atomic.Bool            // If true, List queries may sort on this field
+
+	// Options of the pobj struct tag, set at registration (see Field.Required)
+	index    []int          // Index of the field in its struct, nil if not a field of the object's type
+	required bool           // Field must not be zero
+	readonly bool           // Field is set by the server only
+	hidden   bool           // Field is left out of schemas and manifests
+	min, max *float64       // Bounds of the value, or of the length
+	pattern  *regexp.Regexp // Pattern string values must match
+	enum     []string       // Allowed values
 }
 
 // Method represents a registered method with its metadata.
 // Methods can be either static (class-level) or require an instance in context.
 type Method struct {
-	callable         *typutil.Callable // The underlying callable function
-	doc              string            // Documentation for this method
-	requiresInstance bool              // If true, the object instance must be provided in context
-	object           *Object           // The object this method belongs to
-	name             string            // The method name
+	callable         *typutil.Callable      // The underlying callable function
+	fnType           reflect.Type           // Type of the underlying function
+	doc              atomic.Pointer[string] // Documentation for this method
+	requiresInstance atomic.Bool            // If true, the object instance must be provided in context
+	object           *Object                // The object this method belongs to
+	name             string                 // The method name
+
+	interceptors atomic.Pointer[[]Interceptor] // Interceptors of this method
+	perms        atomic.Pointer[[]string]      // Required permissions
 }
 
 // ObjectActions defines callable factories for REST-like API operations.
@@ -50,99 +92,150 @@ type ObjectActions struct {
 	List   *typutil.Callable // List returns all objects of this type
 	Clear  *typutil.Callable // Clear deletes all objects of this type
 	Create *typutil.Callable // Create instantiates a new object
+	Update *typutil.Callable // Update replaces an existing object identified by ID
+	Patch  *typutil.Callable // Patch partially updates an existing object identified by ID
+	Delete *typutil.Callable // Delete removes a single object by ID
+	Count  *typutil.Callable // Count returns the number of objects of this type
 }
 
-var (
-	// root is the top-level object in the hierarchy
-	root = &Object{
-		children: make(map[string]*Object),
-	}
-	// typLookup provides direct access to objects by their reflected type
-	typLookup = make(map[reflect.Type]*Object)
-	// mu protects access to root and typLookup
-	mu sync.RWMutex
-)
-
-// lookup finds an Object by its path in the hierarchy.
-// If create is true, it will create missing objects along the path.
-// Paths use '/' as a separator, e.g. "user/admin" to locate nested objects.
-// Caller must hold appropriate lock (read lock if create=false, write lock if create=true).
-func lookup(p string, create bool) *Object {
-	c := root
-
-	pa := strings.Split(p, "/")
-
-	for _, s := range pa {
-		if c.children != nil {
-			if x, ok := c.children[s]; ok {
-				c = x
-				continue
-			}
-		}
-		if !create {
-			return nil
-		}
-		if c.children == nil {
-			c.children = make(map[string]*Object)
-		}
-		x := &Object{parent: c, name: s}
-		c.children[s] = x
-		c = x
-	}
-	return c
-}
-
-// Root returns the root object holder, which is the top-level object
-// in the hierarchical registry.
+// Root returns the root object holder of the DefaultRegistry, which is the
+// top-level object in the hierarchical registry.
 func Root() *Object {
-	mu.RLock()
-	defer mu.RUnlock()
-	return root
+	return DefaultRegistry.Root()
 }
 
-// Get returns the Object matching the given name, or nil if no such object exists.
+// Get returns the Object matching the given name in the DefaultRegistry, or
+// nil if no such object exists.
 // The name can be a path using '/' as separator for nested objects.
 func Get(name string) *Object {
-	mu.RLock()
-	defer mu.RUnlock()
-	return lookup(name, false)
+	return DefaultRegistry.Get(name)
+}
+
+// Lookup is like Get but returns an error wrapping ErrInvalidPath if name is
+// not a valid path, or ErrUnknownType if no object exists at that path.
+func Lookup(name string) (*Object, error) {
+	return DefaultRegistry.Lookup(name)
 }
 
-// GetByType returns the Object matching the given generic type parameter.
+// GetByType returns the Object matching the given generic type parameter in
+// the DefaultRegistry.
 // It handles pointer types by unwrapping them to their underlying type.
+// If the type was registered at several paths, the most recent registration
+// is returned (see GetAllByType).
 // Returns nil if the type is not registered.
 func GetByType[T any]() *Object {
-	t := reflect.TypeOf((*T)(nil))
-	for t.Kind() == reflect.Pointer {
-		t = t.Elem()
-	}
-	mu.RLock()
-	defer mu.RUnlock()
-	if o, ok := typLookup[t]; ok {
-		return o
-	}
-	return nil
+	return GetByTypeIn[T](DefaultRegistry)
+}
+
+// GetByTypeIn returns the Object matching the given generic type parameter in
+// registry r. Returns nil if the type is not registered.
+func GetByTypeIn[T any](r *Registry) *Object {
+	return r.GetByType(typeFor[T]())
+}
+
+// GetAllByType returns every Object registered for the generic type parameter
+// in the DefaultRegistry, in registration order.
+func GetAllByType[T any]() []*Object {
+	return GetAllByTypeIn[T](DefaultRegistry)
+}
+
+// GetAllByTypeIn returns every Object registered for the generic type
+// parameter in registry r, in registration order.
+func GetAllByTypeIn[T any](r *Registry) []*Object {
+	return r.GetAllByType(typeFor[T]())
 }
 
 // New creates and returns a new instance of the registered type.
 // Returns nil if the Object doesn't have an associated type.
 // The returned value will be a pointer to a newly allocated instance.
 func (o *Object) New() any {
-	if o.typ == nil {
+	typ := o.rtype()
+	if typ == nil {
+		return nil
+	}
+	return reflect.New(typ).Interface()
+}
+
+// rtype returns the Go type registered for this object, or nil.
+func (o *Object) rtype() reflect.Type {
+	if st := o.state.Load(); st != nil {
+		return st.typ
+	}
+	return nil
+}
+
+// isEmpty returns true if o has no type, no methods, no children, no
+// interceptors and no permissions, and can therefore be removed from the
+// tree.
+func (o *Object) isEmpty() bool {
+	return o.rtype() == nil && len(o.methods.load()) == 0 && len(o.children.load()) == 0 &&
+		o.interceptors.Load() == nil && len(o.perms.load()) == 0
+}
+
+// Actions returns the actions registered for this object, or nil. If the
+// Action field was assigned directly, its value is returned instead.
+func (o *Object) Actions() *ObjectActions {
+	if o == nil {
 		return nil
 	}
-	return reflect.New(o.typ).Interface()
+	if a := o.Action; a != o.action0 {
+		return a
+	}
+	if st := o.state.Load(); st != nil {
+		return st.actions
+	}
+	return nil
 }
 
 // String returns the full path name of this Object in the registry hierarchy.
 // The path uses '/' as a separator between parent and child objects.
 func (o *Object) String() string {
-	switch o.parent {
-	case root, nil:
+	if o.parent == nil || o.parent.parent == nil {
+		// root, or direct child of root
 		return o.name
-	default:
-		return o.parent.String() + "/" + o.name
 	}
+	return o.parent.String() + "/" + o.name
+}
+
+// Parent returns the parent of this Object in the registry hierarchy. Top
+// level objects have the root as parent, and the root has none.
+func (o *Object) Parent() *Object {
+	if o == nil {
+		return nil
+	}
+	return o.parent
+}
+
+// Path returns the segments of the path of this Object, such as
+// ["user", "admin"] for "user/admin". Returns nil for the root.
+func (o *Object) Path() []string {
+	n := o.Depth()
+	if n == 0 {
+		return nil
+	}
+	res := make([]string, n)
+	for c := o; c.parent != nil; c = c.parent {
+		n--
+		res[n] = c.name
+	}
+	return res
+}
+
+// Depth returns the number of segments of the path of this Object: 0 for
+// the root, 1 for top level objects, and so on.
+func (o *Object) Depth() int {
+	n := 0
+	for c := o; c != nil && c.parent != nil; c = c.parent {
+		n++
+	}
+	return n
+}
+
+// HasType returns true if a type is registered for this Object. Objects
+// without type are the root, intermediate objects created for the path of
+// their children, and objects only holding methods.
+func (o *Object) HasType() bool {
+	return o != nil && o.rtype() != nil
 }
 
 // Child retrieves a direct child Object with the given name.
@@ -151,10 +244,7 @@ func (o *Object) Child(name string) *Object {
 	if o == nil {
 		return nil
 	}
-	if o.children == nil {
-		return nil
-	}
-	res, ok := o.children[name]
+	res, ok := o.children.get(name)
 	if !ok {
 		return nil
 	}
@@ -170,10 +260,8 @@ func (o *Object) Static(name string) *typutil.Callable {
 		return nil
 	}
 	// Check new methods map first
-	if o.methods != nil {
-		if m, ok := o.methods[name]; ok {
-			return m.callable
-		}
+	if m, ok := o.methods.get(name); ok {
+		return m.callable
 	}
 	// Fall back to deprecated static map
 	if o.static == nil {
@@ -191,22 +279,28 @@ func (o *Object) Static(name string) *typutil.Callable {
 // such as documentation and whether the method requires an instance.
 // Returns nil if the method doesn't exist.
 func (o *Object) Method(name string) *Method {
-	if o == nil || o.methods == nil {
+	if o == nil {
 		return nil
 	}
-	return o.methods[name]
+	m, _ := o.methods.get(name)
+	return m
 }
 
-// Methods returns the names of all registered methods for this object.
-// Returns nil if the object has no methods.
+// Methods returns the names of all registered methods for this object,
+// sorted. Returns nil if the object has no methods.
 func (o *Object) Methods() []string {
-	if o == nil || o.methods == nil {
+	if o == nil {
+		return nil
+	}
+	m := o.methods.load()
+	if m == nil {
 		return nil
 	}
-	res := make([]string, 0, len(o.methods))
-	for name := range o.methods {
+	res := make([]string, 0, len(m))
+	for name := range m {
 		res = append(res, name)
 	}
+	sort.Strings(res)
 	return res
 }
 
@@ -216,7 +310,7 @@ func (o *Object) SetDoc(doc string) *Object {
 	if o == nil {
 		return nil
 	}
-	o.doc = doc
+	o.doc.Store(&doc)
 	return o
 }
 
@@ -225,32 +319,32 @@ func (o *Object) Doc() string {
 	if o == nil {
 		return ""
 	}
-	return o.doc
+	return loadString(&o.doc)
 }
 
-// Children returns the names of all direct child objects.
+// Children returns the names of all direct child objects, sorted.
 // Returns nil if the object has no children.
 func (o *Object) Children() []string {
-	if o == nil || o.children == nil {
+	if o == nil {
+		return nil
+	}
+	m := o.children.load()
+	if m == nil {
 		return nil
 	}
-	res := make([]string, 0, len(o.children))
-	for name := range o.children {
+	res := make([]string, 0, len(m))
+	for name := range m {
 		res = append(res, name)
 	}
+	sort.Strings(res)
 	return res
 }
 
-// All returns all registered Objects that have an associated type.
+// All returns all registered Objects of the DefaultRegistry that have an
+// associated type, sorted by path.
 // This can be used for introspection and debugging.
 func All() []*Object {
-	mu.RLock()
-	defer mu.RUnlock()
-	res := make([]*Object, 0, len(typLookup))
-	for _, o := range typLookup {
-		res = append(res, o)
-	}
-	return res
+	return DefaultRegistry.All()
 }
 
 // SetDoc sets the documentation for this method and returns the method
@@ -259,7 +353,7 @@ func (m *Method) SetDoc(doc string) *Method {
 	if m == nil {
 		return nil
 	}
-	m.doc = doc
+	m.doc.Store(&doc)
 	return m
 }
 
@@ -268,7 +362,7 @@ func (m *Method) Doc() string {
 	if m == nil {
 		return ""
 	}
-	return m.doc
+	return loadString(&m.doc)
 }
 
 // SetRequiresInstance marks this method as requiring an instance of the
@@ -277,7 +371,7 @@ func (m *Method) SetRequiresInstance(requires bool) *Method {
 	if m == nil {
 		return nil
 	}
-	m.requiresInstance = requires
+	m.requiresInstance.Store(requires)
 	return m
 }
 
@@ -287,7 +381,7 @@ func (m *Method) RequiresInstance() bool {
 	if m == nil {
 		return false
 	}
-	return m.requiresInstance
+	return m.requiresInstance.Load()
 }
 
 // Callable returns the underlying typutil.Callable for this method.
@@ -298,6 +392,29 @@ func (m *Method) Callable() *typutil.Callable {
 	return m.callable
 }
 
+// Type returns the type of the function registered for this method.
+func (m *Method) Type() reflect.Type {
+	if m == nil {
+		return nil
+	}
+	return m.fnType
+}
+
+// ArgTypes returns the types of the arguments of this method, excluding the
+// context.Context argument if any.
+func (m *Method) ArgTypes() []reflect.Type {
+	if m == nil || m.fnType == nil {
+		return nil
+	}
+	var res []reflect.Type
+	for i := 0; i < m.fnType.NumIn(); i++ {
+		if in := m.fnType.In(i); !in.Implements(ctxTyp) {
+			res = append(res, in)
+		}
+	}
+	return res
+}
+
 // Name returns the name of this method.
 func (m *Method) Name() string {
 	if m == nil {
@@ -328,22 +445,28 @@ func (m *Method) String() string {
 // Field returns the field metadata for the given field name.
 // Returns nil if the field doesn't exist or has no metadata.
 func (o *Object) Field(name string) *Field {
-	if o == nil || o.fields == nil {
+	if o == nil {
 		return nil
 	}
-	return o.fields[name]
+	f, _ := o.fields.get(name)
+	return f
 }
 
-// Fields returns the names of all fields with metadata.
+// Fields returns the names of all fields with metadata, sorted.
 // Returns nil if the object has no field metadata.
 func (o *Object) Fields() []string {
-	if o == nil || o.fields == nil {
+	if o == nil {
+		return nil
+	}
+	m := o.fields.load()
+	if m == nil {
 		return nil
 	}
-	res := make([]string, 0, len(o.fields))
-	for name := range o.fields {
+	res := make([]string, 0, len(m))
+	for name := range m {
 		res = append(res, name)
 	}
+	sort.Strings(res)
 	return res
 }
 
@@ -353,35 +476,42 @@ func (o *Object) SetFieldDoc(fieldName, doc string) *Object {
 	if o == nil {
 		return nil
 	}
-	if o.fields == nil {
-		o.fields = make(map[string]*Field)
+	o.field(fieldName).doc.Store(&doc)
+	return o
+}
+
+// field returns the metadata for the given field, creating it if needed.
+func (o *Object) field(fieldName string) *Field {
+	if f, ok := o.fields.get(fieldName); ok {
+		return f
 	}
-	f, ok := o.fields[fieldName]
+	o.reg.mu.Lock()
+	defer o.reg.mu.Unlock()
+	f, ok := o.fields.get(fieldName)
 	if !ok {
 		f = &Field{
 			name:   fieldName,
 			object: o,
 		}
 		// Try to get the type from reflection
-		if o.typ != nil && o.typ.Kind() == reflect.Struct {
-			if sf, found := o.typ.FieldByName(fieldName); found {
+		if typ := o.rtype(); typ != nil && typ.Kind() == reflect.Struct {
+			if sf, found := typ.FieldByName(fieldName); found {
 				f.typ = sf.Type
 			}
 		}
-		o.fields[fieldName] = f
+		o.fields.set(fieldName, f)
 	}
-	f.doc = doc
-	return o
+	return f
 }
 
 // FieldDoc returns the documentation for a field.
 // Returns empty string if the field has no documentation.
 func (o *Object) FieldDoc(fieldName string) string {
-	if o == nil || o.fields == nil {
+	if o == nil {
 		return ""
 	}
-	if f, ok := o.fields[fieldName]; ok {
-		return f.doc
+	if f, ok := o.fields.get(fieldName); ok {
+		return f.Doc()
 	}
 	return ""
 }
@@ -391,7 +521,7 @@ func (f *Field) SetDoc(doc string) *Field {
 	if f == nil {
 		return nil
 	}
-	f.doc = doc
+	f.doc.Store(&doc)
 	return f
 }
 
@@ -400,7 +530,7 @@ func (f *Field) Doc() string {
 	if f == nil {
 		return ""
 	}
-	return f.doc
+	return loadString(&f.doc)
 }
 
 // Name returns the name of this field.
@@ -0,0 +1,73 @@
+package pobj
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// ParsePath validates an object path and returns its segments.
+//
+// A path is a list of segments separated by '/'. Leading and trailing slashes
+// are ignored, so "/user/admin/" is the same as "user/admin". Each segment
+// must be non-empty and contain only letters, digits, '_', '-' and '.', and
+// cannot be "." or "..". The ':' character is reserved to separate an object
+// path from a method name (see ParseMethodRef).
+//
+// Errors returned by ParsePath wrap ErrInvalidPath.
+func ParsePath(p string) ([]string, error) {
+	trimmed := strings.Trim(p, "/")
+	if trimmed == "" {
+		return nil, fmt.Errorf("%w %q: empty path", ErrInvalidPath, p)
+	}
+	segs := strings.Split(trimmed, "/")
+	for _, s := range segs {
+		if err := checkName(s); err != "" {
+			return nil, fmt.Errorf("%w %q: %s", ErrInvalidPath, p, err)
+		}
+	}
+	return segs, nil
+}
+
+// ParseMethodRef parses a method reference in the "object/path:methodName"
+// format, returning the object path segments and the method name. The method
+// name follows the same rules as a path segment.
+//
+// Errors wrap ErrInvalidMethodName if the reference has no ':' separator or
+// an invalid method name, and ErrInvalidPath if the object path is invalid.
+func ParseMethodRef(ref string) ([]string, string, error) {
+	pos := strings.IndexByte(ref, ':')
+	if pos == -1 {
+		return nil, "", fmt.Errorf("%w %q: missing ':' separator", ErrInvalidMethodName, ref)
+	}
+	path, err := ParsePath(ref[:pos])
+	if err != nil {
+		return nil, "", err
+	}
+	name := ref[pos+1:]
+	if err := checkName(name); err != "" {
+		return nil, "", fmt.Errorf("%w %q: %s", ErrInvalidMethodName, ref, err)
+	}
+	return path, name, nil
+}
+
+// checkName validates a single path segment or method name, returning a
+// description of the problem or an empty string if the name is valid.
+func checkName(s string) string {
+	switch s {
+	case "":
+		return "empty segment"
+	case ".", "..":
+		return fmt.Sprintf("reserved segment %q", s)
+	}
+	for _, c := range s {
+		switch {
+		case unicode.IsLetter(c), unicode.IsDigit(c), c == '_', c == '-', c == '.':
+		case c == ':':
+			return "':' is reserved for method names"
+		default:
+			return fmt.Sprintf("invalid character %q", c)
+		}
+	}
+	return ""
+}
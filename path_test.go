@@ -0,0 +1,106 @@
+package pobj_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/KarpelesLab/pobj"
+)
+
+func TestParsePath(t *testing.T) {
+	tests := []struct {
+		path    string
+		want    []string
+		wantErr bool
+	}{
+		{path: "user", want: []string{"user"}},
+		{path: "user/admin", want: []string{"user", "admin"}},
+		{path: "/user/admin/", want: []string{"user", "admin"}},
+		{path: "v1.2/user_info-x", want: []string{"v1.2", "user_info-x"}},
+		{path: "", wantErr: true},
+		{path: "/", wantErr: true},
+		{path: "user//admin", wantErr: true},
+		{path: "user:admin", wantErr: true},
+		{path: "user/../admin", wantErr: true},
+		{path: "user admin", wantErr: true},
+		{path: "user/*", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, err := pobj.ParsePath(tt.path)
+			if tt.wantErr {
+				if !errors.Is(err, pobj.ErrInvalidPath) {
+					t.Errorf("Wrong error, got %v, want %v", err, pobj.ErrInvalidPath)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Wrong segments, got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseMethodRef(t *testing.T) {
+	path, name, err := pobj.ParseMethodRef("/user/admin:getByEmail")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(path, []string{"user", "admin"}) || name != "getByEmail" {
+		t.Errorf("Wrong result, got %v %q", path, name)
+	}
+
+	if _, _, err := pobj.ParseMethodRef("user"); !errors.Is(err, pobj.ErrInvalidMethodName) {
+		t.Errorf("Wrong error, got %v, want %v", err, pobj.ErrInvalidMethodName)
+	}
+	if _, _, err := pobj.ParseMethodRef("user:"); !errors.Is(err, pobj.ErrInvalidMethodName) {
+		t.Errorf("Wrong error, got %v, want %v", err, pobj.ErrInvalidMethodName)
+	}
+	if _, _, err := pobj.ParseMethodRef("user:a:b"); !errors.Is(err, pobj.ErrInvalidMethodName) {
+		t.Errorf("Wrong error, got %v, want %v", err, pobj.ErrInvalidMethodName)
+	}
+	if _, _, err := pobj.ParseMethodRef("user//x:get"); !errors.Is(err, pobj.ErrInvalidPath) {
+		t.Errorf("Wrong error, got %v, want %v", err, pobj.ErrInvalidPath)
+	}
+}
+
+func TestInvalidPathRegistration(t *testing.T) {
+	r := pobj.NewRegistry()
+
+	if _, err := pobj.TryRegisterIn[TestPerson](r, "user//admin"); !errors.Is(err, pobj.ErrInvalidPath) {
+		t.Errorf("Wrong error, got %v, want %v", err, pobj.ErrInvalidPath)
+	}
+	if _, err := pobj.TryRegisterIn[TestPerson](r, ""); !errors.Is(err, pobj.ErrInvalidPath) {
+		t.Errorf("Wrong error, got %v, want %v", err, pobj.ErrInvalidPath)
+	}
+	if _, err := r.TryRegisterMethod("/:get", func() {}); !errors.Is(err, pobj.ErrInvalidPath) {
+		t.Errorf("Wrong error, got %v, want %v", err, pobj.ErrInvalidPath)
+	}
+	if len(r.Root().Children()) != 0 {
+		t.Error("Invalid registrations should not create objects")
+	}
+
+	// Leading and trailing slashes are normalized
+	o := pobj.RegisterIn[TestPerson](r, "/user/")
+	if r.Get("user") != o || r.Get("/user") != o {
+		t.Error("Normalized path lookup failed")
+	}
+	if o.String() != "user" {
+		t.Errorf("Wrong string, got %s, want user", o.String())
+	}
+
+	if _, err := r.Lookup("user//x"); !errors.Is(err, pobj.ErrInvalidPath) {
+		t.Errorf("Wrong error, got %v, want %v", err, pobj.ErrInvalidPath)
+	}
+	if _, err := r.Lookup("nothing"); err != pobj.ErrUnknownType {
+		t.Errorf("Wrong error, got %v, want %v", err, pobj.ErrUnknownType)
+	}
+	if r.Get("user//x") != nil {
+		t.Error("Get should return nil for invalid path")
+	}
+}
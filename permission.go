@@ -0,0 +1,142 @@
+package pobj
+
+import (
+	"context"
+	"fmt"
+)
+
+// Authorizer decides whether the caller of an action or method has a
+// permission. Implementations typically read the principal from ctx, as
+// stored by an authentication middleware.
+type Authorizer interface {
+	// Allow returns true if the caller of inv, as found in ctx, has perm.
+	Allow(ctx context.Context, inv *Invocation, perm string) bool
+}
+
+// AuthorizerFunc is a function implementing Authorizer.
+type AuthorizerFunc func(ctx context.Context, inv *Invocation, perm string) bool
+
+// Allow calls f.
+func (f AuthorizerFunc) Allow(ctx context.Context, inv *Invocation, perm string) bool {
+	return f(ctx, inv, perm)
+}
+
+// SetAuthorizer sets the Authorizer of the DefaultRegistry. See
+// Registry.SetAuthorizer.
+func SetAuthorizer(a Authorizer) {
+	DefaultRegistry.SetAuthorizer(a)
+}
+
+// SetAuthorizer sets the Authorizer checking the permissions required by
+// the objects and methods of this registry (see Object.Require). If no
+// Authorizer is set, calls requiring a permission fail with ErrForbidden.
+func (r *Registry) SetAuthorizer(a Authorizer) {
+	r.authorizer.Store(&a)
+}
+
+// Require declares permissions required to call all the actions and
+// methods of this object and its descendants, and returns the object for
+// chaining. Permissions are checked by the registry's Authorizer before the
+// callable runs, after the interceptors, and calls fail with an error
+// wrapping ErrForbidden if a permission is missing. Permissions are kept
+// when the object is unregistered, see Registry.Unregister.
+//
+//	pobj.Get("admin").Require("admin")
+func (o *Object) Require(perms ...string) *Object {
+	return o.RequireAction("", perms...)
+}
+
+// RequireAction is like Require, but only for the named action, such as
+// "Fetch" or "Delete", of this object and its descendants. The empty action
+// name stands for all actions and methods.
+//
+//	pobj.Get("admin").RequireAction("Delete", "admin.delete")
+func (o *Object) RequireAction(action string, perms ...string) *Object {
+	if o == nil {
+		return nil
+	}
+	o.reg.mu.Lock()
+	defer o.reg.mu.Unlock()
+	cur, _ := o.perms.get(action)
+	o.perms.set(action, appendCopy(cur, perms))
+	return o
+}
+
+// Permissions returns the permissions declared for the named action of this
+// object with RequireAction, or for all actions and methods if action is
+// empty. Permissions inherited from parents are not included.
+func (o *Object) Permissions(action string) []string {
+	if o == nil {
+		return nil
+	}
+	perms, _ := o.perms.get(action)
+	return perms
+}
+
+// Require declares permissions required to call this method, in addition
+// to the ones of its object and its ancestors, and returns the method for
+// chaining.
+//
+//	pobj.Get("user").Method("delete").Require("user.delete")
+func (m *Method) Require(perms ...string) *Method {
+	if m == nil {
+		return nil
+	}
+	m.object.reg.mu.Lock()
+	defer m.object.reg.mu.Unlock()
+	var cur []string
+	if l := m.perms.Load(); l != nil {
+		cur = *l
+	}
+	n := appendCopy(cur, perms)
+	m.perms.Store(&n)
+	return m
+}
+
+// Permissions returns the permissions declared for this method with
+// Require. Permissions of its object are not included.
+func (m *Method) Permissions() []string {
+	if m == nil {
+		return nil
+	}
+	if l := m.perms.Load(); l != nil {
+		return *l
+	}
+	return nil
+}
+
+// authorize checks the permissions required by inv against the Authorizer
+// of its registry: the ones of the objects from the top of the hierarchy
+// down to inv.Object, then the ones of the method.
+func (inv *Invocation) authorize(ctx context.Context) error {
+	var perms []string
+	for o := inv.Object; o != nil; o = o.parent {
+		all, _ := o.perms.get("")
+		action, _ := o.perms.get(inv.Action)
+		if inv.Action == "" {
+			action = nil
+		}
+		perms = append(append(append([]string(nil), all...), action...), perms...)
+	}
+	perms = append(perms, inv.Method.Permissions()...)
+	if len(perms) == 0 {
+		return nil
+	}
+
+	var a Authorizer
+	if p := inv.Object.reg.authorizer.Load(); p != nil {
+		a = *p
+	}
+	for _, perm := range perms {
+		if a == nil || !a.Allow(ctx, inv, perm) {
+			return fmt.Errorf("%w: %s requires %q", ErrForbidden, inv, perm)
+		}
+	}
+	return nil
+}
+
+// appendCopy returns a new slice holding the values of cur then v.
+func appendCopy[T any](cur, v []T) []T {
+	n := make([]T, 0, len(cur)+len(v))
+	return append(append(n, cur...), v...)
+}
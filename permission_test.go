@@ -0,0 +1,159 @@
+package pobj_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/KarpelesLab/pobj"
+	"github.com/KarpelesLab/typutil"
+)
+
+// principalKey is the context key of the permissions of the test principal.
+type principalKey struct{}
+
+func withPerms(ctx context.Context, perms ...string) context.Context {
+	return context.WithValue(ctx, principalKey{}, perms)
+}
+
+func TestPermissions(t *testing.T) {
+	r := pobj.NewRegistry()
+	ctx := context.Background()
+	called := 0
+
+	fetch := func(ctx context.Context, id string) (*TestPerson, error) {
+		called++
+		return &TestPerson{ID: id}, nil
+	}
+	del := func(ctx context.Context, id string) error {
+		called++
+		return nil
+	}
+	pobj.RegisterActionsIn[TestPerson](r, "admin/person", &pobj.ObjectActions{
+		Fetch:  typutil.Func(fetch),
+		Delete: typutil.Func(del),
+	})
+	pobj.RegisterActionsIn[TestCompany](r, "company", &pobj.ObjectActions{
+		Fetch: typutil.Func(func(ctx context.Context, id string) (*TestCompany, error) { return &TestCompany{ID: id}, nil }),
+	})
+	wipe := r.RegisterMethod("admin/person:wipe", func() int { called++; return 1 }).Require("wipe")
+	// called counts the Fetch of the instance
+	rename := r.RegisterMethod("admin/person:rename", func(ctx context.Context) error { return nil }).
+		SetRequiresInstance(true).Require("rename")
+
+	r.Get("admin").Require("admin").RequireAction("Delete", "delete")
+
+	if !reflect.DeepEqual(r.Get("admin").Permissions(""), []string{"admin"}) ||
+		!reflect.DeepEqual(r.Get("admin").Permissions("Delete"), []string{"delete"}) ||
+		!reflect.DeepEqual(wipe.Permissions(), []string{"wipe"}) {
+		t.Error("wrong declared permissions")
+	}
+
+	// no authorizer: calls requiring a permission are denied
+	if _, err := r.Get("admin/person").ById(ctx, "p1"); !errors.Is(err, pobj.ErrForbidden) {
+		t.Errorf("wrong error without authorizer, got %v", err)
+	}
+	// objects without requirements are not checked
+	if _, err := pobj.ByIdIn[TestCompany](ctx, r, "c1"); err != nil {
+		t.Errorf("ById on company failed: %v", err)
+	}
+
+	var checked []string
+	r.SetAuthorizer(pobj.AuthorizerFunc(func(ctx context.Context, inv *pobj.Invocation, perm string) bool {
+		checked = append(checked, inv.String()+" "+perm)
+		perms, _ := ctx.Value(principalKey{}).([]string)
+		for _, p := range perms {
+			if p == perm {
+				return true
+			}
+		}
+		return false
+	}))
+
+	tests := []struct {
+		name  string
+		perms []string
+		call  func(ctx context.Context) error
+		ok    bool
+	}{
+		{"fetch as admin", []string{"admin"}, func(ctx context.Context) error {
+			_, err := pobj.ByIdIn[TestPerson](ctx, r, "p1")
+			return err
+		}, true},
+		{"fetch as nobody", nil, func(ctx context.Context) error {
+			_, err := pobj.ByIdIn[TestPerson](ctx, r, "p1")
+			return err
+		}, false},
+		{"delete as admin", []string{"admin"}, func(ctx context.Context) error {
+			return pobj.DeleteIn[TestPerson](ctx, r, "p1")
+		}, false},
+		{"delete as admin with delete", []string{"admin", "delete"}, func(ctx context.Context) error {
+			return pobj.DeleteIn[TestPerson](ctx, r, "p1")
+		}, true},
+		{"method as admin", []string{"admin"}, func(ctx context.Context) error {
+			_, err := wipe.Invoke(ctx, nil)
+			return err
+		}, false},
+		{"method as admin with wipe", []string{"admin", "wipe"}, func(ctx context.Context) error {
+			_, err := wipe.Invoke(ctx, nil)
+			return err
+		}, true},
+		{"method on ID as admin", []string{"admin"}, func(ctx context.Context) error {
+			_, err := rename.Invoke(ctx, "p1")
+			return err
+		}, false},
+		{"method on ID as admin with rename", []string{"admin", "rename"}, func(ctx context.Context) error {
+			_, err := rename.Invoke(ctx, "p1")
+			return err
+		}, true},
+	}
+	for _, tt := range tests {
+		called = 0
+		err := tt.call(withPerms(ctx, tt.perms...))
+		if tt.ok && (err != nil || called != 1) {
+			t.Errorf("%s: expected success, got %v (called %d)", tt.name, err, called)
+		}
+		if !tt.ok && (!errors.Is(err, pobj.ErrForbidden) || called != 0) {
+			t.Errorf("%s: expected ErrForbidden before the call, got %v (called %d)", tt.name, err, called)
+		}
+	}
+
+	// the method is checked before its instance is fetched
+	checked = nil
+	rename.Invoke(withPerms(ctx, "admin"), "p1")
+	if want := []string{"admin/person:rename admin", "admin/person:rename rename"}; !reflect.DeepEqual(checked, want) {
+		t.Errorf("wrong checks, got %v, want %v", checked, want)
+	}
+
+	checked = nil
+	wipe.Invoke(withPerms(ctx, "admin", "wipe"), nil)
+	if want := []string{"admin/person:wipe admin", "admin/person:wipe wipe"}; !reflect.DeepEqual(checked, want) {
+		t.Errorf("wrong checks, got %v, want %v", checked, want)
+	}
+}
+
+func TestPermissionsAfterInterceptors(t *testing.T) {
+	r := pobj.NewRegistry()
+	ctx := context.Background()
+
+	m := r.RegisterMethod("tools:run", func() string { return "ok" }).Require("run")
+	r.SetAuthorizer(pobj.AuthorizerFunc(func(ctx context.Context, inv *pobj.Invocation, perm string) bool {
+		return ctx.Value(principalKey{}) != nil
+	}))
+	// an interceptor can authenticate the caller, and sees denied calls
+	var denied error
+	r.Use(func(ctx context.Context, inv *pobj.Invocation, next pobj.Invoker) (any, error) {
+		res, err := next(withPerms(ctx, "run"), inv)
+		denied = err
+		return res, err
+	})
+	if res, err := m.Invoke(ctx, nil); err != nil || res != "ok" || denied != nil {
+		t.Errorf("Invoke failed, got %v, %v", res, err)
+	}
+
+	r.SetAuthorizer(pobj.AuthorizerFunc(func(ctx context.Context, inv *pobj.Invocation, perm string) bool { return false }))
+	if _, err := m.Invoke(ctx, nil); !errors.Is(err, pobj.ErrForbidden) || !errors.Is(denied, pobj.ErrForbidden) {
+		t.Errorf("wrong errors, got %v and %v", err, denied)
+	}
+}
@@ -0,0 +1,559 @@
+// Package pobjdiff compares two registry manifests and reports the changes
+// of the API, telling breaking changes apart from additive ones:
+//
+//	changes := pobjdiff.Compare(old, new)
+//	for _, c := range changes {
+//		fmt.Println(c)
+//	}
+//	if pobjdiff.HasBreaking(changes) {
+//		os.Exit(1)
+//	}
+//
+// Breaking changes are the ones that can make existing clients fail:
+// removed objects, aliases, actions, methods and fields, changed field
+// types and type IDs, changed method signatures and narrowed argument
+// types. Adding any of these is an additive change, as is widening an
+// argument type, for instance from integer to number.
+//
+// Values sent by clients, such as method arguments and the objects of
+// Create, Update and Patch, are also broken by new required fields and by
+// narrower enum, pattern, minimum and maximum constraints. Values received
+// by clients are broken by fields that are no longer required and by wider
+// constraints.
+//
+// Manifests are produced by pobj.Registry.Manifest, or read from files
+// written by pobj-manifest with pobj.LoadManifest.
+package pobjdiff
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/KarpelesLab/pobj"
+)
+
+// schemaPrefix is the prefix of $ref values in manifest schemas.
+const schemaPrefix = "#/schemas/"
+
+// Change is a difference between two manifests.
+type Change struct {
+	Breaking bool   // true if existing clients may fail
+	Path     string // object path, or method reference such as "user:rename"
+	Message  string
+}
+
+func (c *Change) String() string {
+	if c.Breaking {
+		return "BREAKING " + c.Path + ": " + c.Message
+	}
+	return c.Path + ": " + c.Message
+}
+
+// HasBreaking returns true if changes contain a breaking change.
+func HasBreaking(changes []*Change) bool {
+	for _, c := range changes {
+		if c.Breaking {
+			return true
+		}
+	}
+	return false
+}
+
+// Compare returns the changes from manifest old to manifest new, sorted by
+// object path. Objects are matched by path or alias, so an object moved to
+// a new path keeping its old one as alias is not reported as removed.
+func Compare(old, new *pobj.Manifest) []*Change {
+	d := &differ{old: old, new: new, seen: make(map[string]bool)}
+	d.inputs, d.outputs = usage(new)
+
+	byPath := make(map[string]*pobj.ObjectManifest)
+	for _, om := range new.Objects {
+		byPath[om.Path] = om
+		for _, a := range om.Aliases {
+			byPath[a] = om
+		}
+	}
+	matched := make(map[*pobj.ObjectManifest]bool)
+	for _, oo := range old.Objects {
+		no := byPath[oo.Path]
+		if no == nil {
+			d.breaking(oo.Path, "object removed")
+			continue
+		}
+		matched[no] = true
+		d.object(oo, no)
+	}
+	for _, no := range new.Objects {
+		if !matched[no] {
+			d.additive(no.Path, "object added")
+		}
+	}
+	sort.SliceStable(d.changes, func(i, j int) bool {
+		return objectPath(d.changes[i].Path) < objectPath(d.changes[j].Path)
+	})
+	return d.changes
+}
+
+// objectPath returns the object part of a change path.
+func objectPath(path string) string {
+	if p, _, ok := strings.Cut(path, ":"); ok {
+		return p
+	}
+	return path
+}
+
+// differ accumulates the changes between two manifests.
+type differ struct {
+	old, new *pobj.Manifest
+	changes  []*Change
+	seen     map[string]bool // schema definitions being compared, to stop recursion
+
+	// Definitions of new sent and received by clients, see usage
+	inputs, outputs map[string]bool
+}
+
+func (d *differ) breaking(path, format string, args ...any) {
+	d.changes = append(d.changes, &Change{Breaking: true, Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+func (d *differ) additive(path, format string, args ...any) {
+	d.changes = append(d.changes, &Change{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// object compares the descriptions of an object.
+func (d *differ) object(oo, no *pobj.ObjectManifest) {
+	path := oo.Path
+	if oo.Path != no.Path {
+		d.additive(path, "object moved to %s", no.Path)
+	}
+	if oo.TypeID != 0 && no.TypeID != 0 && oo.TypeID != no.TypeID {
+		// values encoded with the old ID cannot be decoded
+		d.breaking(path, "type ID changed from %d to %d", oo.TypeID, no.TypeID)
+	}
+
+	// aliases are compared as paths, relative to the new object
+	newPaths := map[string]bool{no.Path: true}
+	for _, a := range no.Aliases {
+		newPaths[a] = true
+	}
+	for _, a := range oo.Aliases {
+		if !newPaths[a] {
+			d.breaking(path, "alias %s removed", a)
+		}
+	}
+	oldPaths := map[string]bool{oo.Path: true}
+	for _, a := range oo.Aliases {
+		oldPaths[a] = true
+	}
+	for _, a := range no.Aliases {
+		if !oldPaths[a] {
+			d.additive(path, "alias %s added", a)
+		}
+	}
+
+	for _, a := range oo.Actions {
+		if !no.HasAction(a) {
+			d.breaking(path, "action %s removed", a)
+		}
+	}
+	for _, a := range no.Actions {
+		if !oo.HasAction(a) {
+			d.additive(path, "action %s added", a)
+		}
+	}
+
+	d.fields(oo, no)
+
+	for _, om := range oo.Methods {
+		nm := no.Method(om.Name)
+		if nm == nil {
+			d.breaking(path+":"+om.Name, "method removed")
+			continue
+		}
+		d.method(path+":"+om.Name, om, nm)
+	}
+	for _, nm := range no.Methods {
+		if oo.Method(nm.Name) == nil {
+			d.additive(path+":"+nm.Name, "method added")
+		}
+	}
+}
+
+// fields compares the fields of an object, matched by JSON name. Their
+// constraints are found in the schemas of the object.
+func (d *differ) fields(oo, no *pobj.ObjectManifest) {
+	path := oo.Path
+	input, output := d.inputs[no.Path], d.outputs[no.Path]
+	oldSchema, newSchema := d.old.Schemas[oo.Path], d.new.Schemas[no.Path]
+
+	byName := make(map[string]*pobj.FieldManifest)
+	for _, f := range no.Fields {
+		byName[f.JSON] = f
+	}
+	known := make(map[string]bool)
+	for _, of := range oo.Fields {
+		known[of.JSON] = true
+		nf := byName[of.JSON]
+		if nf == nil {
+			d.breaking(path, "field %s removed", of.JSON)
+			continue
+		}
+		if of.Type != nf.Type {
+			d.breaking(path, "field %s type changed from %s to %s", of.JSON, of.Type, nf.Type)
+		}
+		if of.Filterable && !nf.Filterable {
+			d.breaking(path, "field %s is no longer filterable", of.JSON)
+		} else if !of.Filterable && nf.Filterable {
+			d.additive(path, "field %s is now filterable", of.JSON)
+		}
+		if of.Sortable && !nf.Sortable {
+			d.breaking(path, "field %s is no longer sortable", of.JSON)
+		} else if !of.Sortable && nf.Sortable {
+			d.additive(path, "field %s is now sortable", of.JSON)
+		}
+		fieldInput := input && !nf.ReadOnly
+		if !of.Required && nf.Required {
+			d.change(fieldInput, path, "field %s is now required", of.JSON)
+		} else if of.Required && !nf.Required {
+			d.change(output, path, "field %s is no longer required", of.JSON)
+		}
+		if oldSchema != nil && newSchema != nil {
+			op, np := oldSchema.Properties[of.JSON], newSchema.Properties[nf.JSON]
+			if op != nil && np != nil {
+				d.constraints(path, "field "+of.JSON, op, np, fieldInput, output)
+			}
+		}
+	}
+	for _, nf := range no.Fields {
+		if known[nf.JSON] {
+			continue
+		}
+		if nf.Required {
+			d.change(input && !nf.ReadOnly, path, "required field %s added", nf.JSON)
+		} else {
+			d.additive(path, "field %s added", nf.JSON)
+		}
+	}
+}
+
+// method compares the signatures of a method.
+func (d *differ) method(path string, om, nm *pobj.MethodManifest) {
+	if om.RequiresInstance != nm.RequiresInstance {
+		if nm.RequiresInstance {
+			d.breaking(path, "method now requires an instance")
+		} else {
+			d.breaking(path, "method no longer requires an instance")
+		}
+	}
+	if len(om.Args) != len(nm.Args) {
+		d.breaking(path, "number of arguments changed from %d to %d", len(om.Args), len(nm.Args))
+	} else {
+		for i := range om.Args {
+			d.schema(path, fmt.Sprintf("argument %d", i), om.Args[i].Schema, nm.Args[i].Schema, true)
+		}
+	}
+	if om.Variadic && !nm.Variadic {
+		d.breaking(path, "method is no longer variadic")
+	}
+
+	or, nr := result(om), result(nm)
+	switch {
+	case or == nil && nr != nil:
+		d.additive(path, "result added")
+	case or != nil && nr == nil:
+		d.breaking(path, "result removed")
+	case or != nil:
+		d.schema(path, "result", or.Schema, nr.Schema, false)
+	}
+}
+
+// schema compares the schemas of a value. If input is true, the value is
+// sent by clients, and new must accept all the values old accepted.
+// Otherwise, the value is received by clients, and new must not produce
+// values old could not.
+func (d *differ) schema(path, what string, old, new *pobj.Schema, input bool) {
+	old, oldRef := d.resolve(d.old, old)
+	new, newRef := d.resolve(d.new, new)
+	if oldRef != "" && oldRef == newRef {
+		if d.old.Object(oldRef) != nil {
+			// registered object, compared with its fields
+			return
+		}
+		key := fmt.Sprintf("%s|%s|%t", path, oldRef, input)
+		if d.seen[key] {
+			return
+		}
+		d.seen[key] = true
+	}
+	if old == nil || new == nil {
+		// unknown definitions, nothing to compare
+		return
+	}
+
+	if old.Type != new.Type || old.Format != new.Format || old.ContentEncoding != new.ContentEncoding {
+		oldType, newType := typeString(old), typeString(new)
+		switch {
+		case input && widens(old, new):
+			d.additive(path, "%s widened from %s to %s", what, oldType, newType)
+		case input:
+			d.breaking(path, "%s narrowed from %s to %s", what, oldType, newType)
+		default:
+			d.breaking(path, "%s type changed from %s to %s", what, oldType, newType)
+		}
+		return
+	}
+
+	d.constraints(path, what, old, new, input, !input)
+
+	for _, name := range sortedKeys(old.Properties) {
+		op, np := old.Properties[name], new.Properties[name]
+		if np == nil {
+			d.breaking(path, "%s property %s removed", what, name)
+			continue
+		}
+		oldReq, newReq := slices.Contains(old.Required, name), slices.Contains(new.Required, name)
+		if !oldReq && newReq {
+			d.change(input, path, "%s property %s is now required", what, name)
+		} else if oldReq && !newReq {
+			d.change(!input, path, "%s property %s is no longer required", what, name)
+		}
+		d.schema(path, what+" property "+name, op, np, input)
+	}
+	for _, name := range sortedKeys(new.Properties) {
+		if _, ok := old.Properties[name]; ok {
+			continue
+		}
+		if slices.Contains(new.Required, name) {
+			d.change(input, path, "%s required property %s added", what, name)
+		} else {
+			d.additive(path, "%s property %s added", what, name)
+		}
+	}
+	if old.Items != nil && new.Items != nil {
+		d.schema(path, what+" items", old.Items, new.Items, input)
+	}
+	if old.AdditionalProperties != nil && new.AdditionalProperties != nil {
+		d.schema(path, what+" values", old.AdditionalProperties, new.AdditionalProperties, input)
+	}
+}
+
+// change records a change, breaking if breaks is true.
+func (d *differ) change(breaks bool, path, format string, args ...any) {
+	if breaks {
+		d.breaking(path, format, args...)
+	} else {
+		d.additive(path, format, args...)
+	}
+}
+
+// constraints compares the enum, pattern, minimum and maximum keywords of
+// the schemas of a value. Narrower constraints break clients sending the
+// value if input is true, and wider ones break clients receiving it if
+// output is true.
+func (d *differ) constraints(path, what string, old, new *pobj.Schema, input, output bool) {
+	restrict := func(keyword string, narrowed, widened bool, detail string) {
+		switch {
+		case narrowed && widened:
+			d.change(input || output, path, "%s %s changed%s", what, keyword, detail)
+		case narrowed:
+			d.change(input, path, "%s %s narrowed%s", what, keyword, detail)
+		case widened:
+			d.change(output, path, "%s %s widened%s", what, keyword, detail)
+		}
+	}
+
+	oldEnum, newEnum := enumSet(old.Enum), enumSet(new.Enum)
+	switch {
+	case oldEnum == nil && newEnum != nil:
+		restrict("enum", true, false, "")
+	case oldEnum != nil && newEnum == nil:
+		restrict("enum", false, true, "")
+	case oldEnum != nil:
+		restrict("enum", !subset(oldEnum, newEnum), !subset(newEnum, oldEnum), "")
+	}
+
+	if old.Pattern != new.Pattern {
+		restrict("pattern", new.Pattern != "", old.Pattern != "", fmt.Sprintf(" from %q to %q", old.Pattern, new.Pattern))
+	}
+
+	bounds := []struct {
+		keyword  string
+		old, new *float64
+		min      bool
+	}{
+		{"minimum", old.Minimum, new.Minimum, true},
+		{"maximum", old.Maximum, new.Maximum, false},
+		{"minLength", intBound(old.MinLength), intBound(new.MinLength), true},
+		{"maxLength", intBound(old.MaxLength), intBound(new.MaxLength), false},
+		{"minItems", intBound(old.MinItems), intBound(new.MinItems), true},
+		{"maxItems", intBound(old.MaxItems), intBound(new.MaxItems), false},
+	}
+	for _, b := range bounds {
+		switch {
+		case b.old == nil && b.new == nil:
+		case b.old == nil:
+			restrict(b.keyword, true, false, " to "+formatBound(b.new))
+		case b.new == nil:
+			restrict(b.keyword, false, true, " from "+formatBound(b.old))
+		case *b.old != *b.new:
+			// a higher minimum or a lower maximum accepts fewer values
+			narrowed := (*b.new > *b.old) == b.min
+			restrict(b.keyword, narrowed, !narrowed, " from "+formatBound(b.old)+" to "+formatBound(b.new))
+		}
+	}
+}
+
+// resolve returns the schema referenced by s in m, and the name of the
+// definition, if s is a reference.
+func (d *differ) resolve(m *pobj.Manifest, s *pobj.Schema) (*pobj.Schema, string) {
+	if s == nil || s.Ref == "" {
+		return s, ""
+	}
+	name := strings.TrimPrefix(s.Ref, schemaPrefix)
+	name = strings.ReplaceAll(strings.ReplaceAll(name, "~1", "/"), "~0", "~")
+	return m.Schemas[name], name
+}
+
+// widens returns true if schema new accepts all the values of schema old.
+func widens(old, new *pobj.Schema) bool {
+	switch {
+	case new.Type == "" && new.Ref == "":
+		// any value
+		return true
+	case old.Type == "integer" && new.Type == "number":
+		return true
+	case old.Type == new.Type && new.Format == "" && new.ContentEncoding == "":
+		// format removed
+		return true
+	}
+	return false
+}
+
+// typeString describes the type of schema s, such as "string (date-time)".
+func typeString(s *pobj.Schema) string {
+	t := s.Type
+	if t == "" {
+		t = "any"
+	}
+	if s.Format != "" {
+		t += " (" + s.Format + ")"
+	}
+	if s.ContentEncoding != "" {
+		t += " (" + s.ContentEncoding + ")"
+	}
+	return t
+}
+
+// enumSet returns the values of enum as strings, or nil if enum is empty.
+func enumSet(enum []any) map[string]bool {
+	if len(enum) == 0 {
+		return nil
+	}
+	set := make(map[string]bool, len(enum))
+	for _, v := range enum {
+		set[fmt.Sprint(v)] = true
+	}
+	return set
+}
+
+// subset returns true if all the values of a are in b.
+func subset(a, b map[string]bool) bool {
+	for v := range a {
+		if !b[v] {
+			return false
+		}
+	}
+	return true
+}
+
+// intBound returns n as a float64, or nil.
+func intBound(n *int) *float64 {
+	if n == nil {
+		return nil
+	}
+	f := float64(*n)
+	return &f
+}
+
+// formatBound formats the value of a minimum or maximum keyword.
+func formatBound(f *float64) string {
+	return strconv.FormatFloat(*f, 'g', -1, 64)
+}
+
+// usage returns the names of the schema definitions of m holding values
+// sent by clients, as the objects of Create, Update and Patch and the method
+// arguments, and the ones holding values received by clients, as the
+// objects of actions and the method results. Definitions referenced by
+// these are included.
+func usage(m *pobj.Manifest) (inputs, outputs map[string]bool) {
+	inputs, outputs = make(map[string]bool), make(map[string]bool)
+	var walk func(set map[string]bool, s *pobj.Schema)
+	use := func(set map[string]bool, name string) {
+		if !set[name] {
+			set[name] = true
+			walk(set, m.Schemas[name])
+		}
+	}
+	walk = func(set map[string]bool, s *pobj.Schema) {
+		if s == nil {
+			return
+		}
+		if s.Ref != "" {
+			name := strings.TrimPrefix(s.Ref, schemaPrefix)
+			use(set, strings.ReplaceAll(strings.ReplaceAll(name, "~1", "/"), "~0", "~"))
+		}
+		for _, p := range s.Properties {
+			walk(set, p)
+		}
+		for _, p := range s.PrefixItems {
+			walk(set, p)
+		}
+		walk(set, s.Items)
+		walk(set, s.AdditionalProperties)
+	}
+
+	for _, om := range m.Objects {
+		for _, a := range om.Actions {
+			switch a {
+			case "Create", "Update", "Patch":
+				use(inputs, om.Path)
+				use(outputs, om.Path)
+			case "Fetch", "List":
+				use(outputs, om.Path)
+			}
+		}
+		for _, mm := range om.Methods {
+			for _, a := range mm.Args {
+				walk(inputs, a.Schema)
+			}
+			for _, r := range mm.Results {
+				walk(outputs, r.Schema)
+			}
+		}
+	}
+	return inputs, outputs
+}
+
+// sortedKeys returns the keys of m in sorted order.
+func sortedKeys(m map[string]*pobj.Schema) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// result returns the result of method m, ignoring the error result, or nil
+// if it has none.
+func result(m *pobj.MethodManifest) *pobj.TypeManifest {
+	for _, r := range m.Results {
+		if r.Go != "error" {
+			return r
+		}
+	}
+	return nil
+}
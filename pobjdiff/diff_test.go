@@ -0,0 +1,194 @@
+package pobjdiff_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/KarpelesLab/pobj"
+	"github.com/KarpelesLab/pobj/pobjdiff"
+	"github.com/KarpelesLab/typutil"
+)
+
+type userV1 struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Age   int    `json:"age"`
+	Email string `json:"email"`
+}
+
+type userV2 struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Age  int64  `json:"age"`
+	Nick string `json:"nick,omitempty"`
+}
+
+type filter struct {
+	Limit float64 `json:"limit"`
+	Query string  `json:"query"`
+}
+
+type filterV2 struct {
+	Limit int    `json:"limit"`
+	Query string `json:"query"`
+	Tags  []int  `json:"tags,omitempty"`
+}
+
+func fetch[T any](ctx context.Context, id string) (*T, error) { return nil, nil }
+
+func TestCompare(t *testing.T) {
+	old := pobj.NewRegistry()
+	pobj.RegisterActionsIn[userV1](old, "user", &pobj.ObjectActions{
+		Fetch:  typutil.Func(fetch[userV1]),
+		Delete: typutil.Func(func(ctx context.Context, id string) error { return nil }),
+	}).Alias("legacy/user")
+	old.RegisterMethod("user:search", func(f *filter) ([]string, error) { return nil, nil })
+	old.RegisterMethod("user:scale", func(n int) int { return n })
+	old.RegisterMethod("user:ping", func() {})
+	old.RegisterMethod("user:close", func() error { return nil }).SetRequiresInstance(true)
+	pobj.RegisterIn[userV1](old, "gone")
+
+	cur := pobj.NewRegistry()
+	pobj.RegisterActionsIn[userV2](cur, "user", &pobj.ObjectActions{
+		Fetch: typutil.Func(fetch[userV2]),
+		List:  typutil.Func(func(ctx context.Context) ([]*userV2, error) { return nil, nil }),
+	}).Alias("v2/user").SetFilterable("Name")
+	cur.RegisterMethod("user:search", func(f *filterV2) ([]string, error) { return nil, nil })
+	cur.RegisterMethod("user:scale", func(n float64) int { return int(n) })
+	cur.RegisterMethod("user:ping", func() string { return "pong" })
+	cur.RegisterMethod("user:close", func(force bool) error { return nil })
+	cur.RegisterMethod("tools:version", func() string { return "2" })
+
+	var got []string
+	for _, c := range pobjdiff.Compare(old.Manifest(), cur.Manifest()) {
+		got = append(got, c.String())
+	}
+	want := []string{
+		"BREAKING gone: object removed",
+		"tools: object added",
+		"BREAKING user: type ID changed from 2 to 1",
+		"BREAKING user: alias legacy/user removed",
+		"user: alias v2/user added",
+		"BREAKING user: action Delete removed",
+		"user: action List added",
+		"user: field name is now filterable",
+		"BREAKING user: field age type changed from int to int64",
+		"BREAKING user: field email removed",
+		"user: field nick added",
+		"BREAKING user:close: method no longer requires an instance",
+		"BREAKING user:close: number of arguments changed from 0 to 1",
+		"user:ping: result added",
+		"user:scale: argument 0 widened from integer to number",
+		"BREAKING user:search: argument 0 property limit narrowed from number to integer",
+		"user:search: argument 0 property tags added",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("wrong changes, got:\n%q\nwant:\n%q", got, want)
+	}
+
+	if changes := pobjdiff.Compare(cur.Manifest(), cur.Manifest()); len(changes) != 0 {
+		t.Errorf("expected no changes, got %v", changes)
+	}
+}
+
+func TestCompareMoved(t *testing.T) {
+	old := pobj.NewRegistry()
+	pobj.RegisterIn[userV1](old, "user")
+	cur := pobj.NewRegistry()
+	pobj.RegisterIn[userV1](cur, "account/user").Alias("user")
+
+	changes := pobjdiff.Compare(old.Manifest(), cur.Manifest())
+	if pobjdiff.HasBreaking(changes) || len(changes) != 1 || changes[0].String() != "user: object moved to account/user" {
+		t.Errorf("wrong changes for a moved object, got %v", changes)
+	}
+
+	changes = pobjdiff.Compare(cur.Manifest(), old.Manifest())
+	if !pobjdiff.HasBreaking(changes) {
+		t.Errorf("removing account/user should be breaking, got %v", changes)
+	}
+}
+
+type accountV1 struct {
+	ID    string `json:"id" pobj:"readonly"`
+	Name  string `json:"name,omitempty"`
+	Role  string `json:"role" pobj:"enum=user|admin"`
+	Code  string `json:"code,omitempty"`
+	Quota int    `json:"quota" pobj:"max=100"`
+}
+
+type accountV2 struct {
+	ID    string `json:"id" pobj:"readonly"`
+	Name  string `json:"name" pobj:"required"`
+	Role  string `json:"role" pobj:"enum=user"`
+	Code  string `json:"code,omitempty" pobj:"pattern=^[A-Z]+$"`
+	Quota int    `json:"quota" pobj:"max=10"`
+}
+
+type queryV1 struct {
+	Limit int `json:"limit" pobj:"min=1"`
+}
+
+type queryV2 struct {
+	Limit int    `json:"limit" pobj:"min=0"`
+	Sort  string `json:"sort" pobj:"enum=asc|desc"`
+}
+
+func TestCompareConstraints(t *testing.T) {
+	actions := &pobj.ObjectActions{
+		Create: typutil.Func(func(ctx context.Context, a *accountV1) (*accountV1, error) { return a, nil }),
+	}
+	old := pobj.NewRegistry()
+	pobj.RegisterActionsIn[accountV1](old, "account", actions)
+	pobj.RegisterIn[accountV1](old, "report")
+	old.RegisterMethod("report:run", func(q *queryV1) ([]*accountV1, error) { return nil, nil })
+	cur := pobj.NewRegistry()
+	pobj.RegisterActionsIn[accountV2](cur, "account", actions)
+	pobj.RegisterIn[accountV2](cur, "report")
+	cur.RegisterMethod("report:run", func(q *queryV2) ([]*accountV2, error) { return nil, nil })
+
+	var got []string
+	for _, c := range pobjdiff.Compare(old.Manifest(), cur.Manifest()) {
+		got = append(got, c.String())
+	}
+	want := []string{
+		// sent with Create
+		"BREAKING account: field name is now required",
+		"BREAKING account: field role enum narrowed",
+		"BREAKING account: field code pattern narrowed from \"\" to \"^[A-Z]+$\"",
+		"BREAKING account: field quota maximum narrowed from 100 to 10",
+		// only received by clients
+		"report: field name is now required",
+		"report: field role enum narrowed",
+		"report: field code pattern narrowed from \"\" to \"^[A-Z]+$\"",
+		"report: field quota maximum narrowed from 100 to 10",
+		"report:run: argument 0 property limit minimum widened from 1 to 0",
+		"BREAKING report:run: argument 0 required property sort added",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("wrong changes, got:\n%q\nwant:\n%q", got, want)
+	}
+
+	// the reverse changes break the clients receiving the values
+	var breaking []string
+	for _, c := range pobjdiff.Compare(cur.Manifest(), old.Manifest()) {
+		if c.Breaking {
+			breaking = append(breaking, c.String())
+		}
+	}
+	want = []string{
+		"BREAKING account: field name is no longer required",
+		"BREAKING account: field role enum widened",
+		"BREAKING account: field code pattern widened from \"^[A-Z]+$\" to \"\"",
+		"BREAKING account: field quota maximum widened from 10 to 100",
+		"BREAKING report: field name is no longer required",
+		"BREAKING report: field role enum widened",
+		"BREAKING report: field code pattern widened from \"^[A-Z]+$\" to \"\"",
+		"BREAKING report: field quota maximum widened from 10 to 100",
+		"BREAKING report:run: argument 0 property limit minimum narrowed from 0 to 1",
+		"BREAKING report:run: argument 0 property sort removed",
+	}
+	if !reflect.DeepEqual(breaking, want) {
+		t.Errorf("wrong breaking changes, got:\n%q\nwant:\n%q", breaking, want)
+	}
+}
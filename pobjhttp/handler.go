@@ -0,0 +1,437 @@
+// Package pobjhttp exposes the objects of a pobj registry as a JSON REST API.
+//
+// Routes are derived from the registry tree, starting from its root object:
+//
+//	GET    /user              List (or Count with ?count)
+//	POST   /user              Create
+//	DELETE /user              Clear
+//	GET    /user/{id}         Fetch (see pobj.Object.ById)
+//	PUT    /user/{id}         Update
+//	PATCH  /user/{id}         Patch
+//	DELETE /user/{id}         Delete
+//	POST   /user:method       call a static method
+//	POST   /user/{id}:method  call a method on the instance fetched by id
+//
+// Child objects take precedence over IDs, so /user/admin refers to the object
+// registered as "user/admin" if any, and to the user with ID "admin" otherwise.
+// Likewise, /user/urn:x calls method x on the user with ID "urn" if the
+// object has a method named x, and refers to the user with ID "urn:x"
+// otherwise; a ':' escaped as %3A is always part of the ID. Static methods
+// cannot be called on an ID.
+//
+// Request bodies are JSON. The body of Create, Update and Patch is passed as
+// the data argument of the action, and the body of a method call holds its
+// argument, or a JSON array of its arguments if it takes more than one.
+// Results are encoded as JSON, and errors as a JSON object with an "error"
+// field and a status code derived from the error (see StatusCode).
+package pobjhttp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/KarpelesLab/pobj"
+	"github.com/KarpelesLab/typutil"
+)
+
+// DefaultMaxBodySize is the maximum size of a request body used when
+// Handler.MaxBodySize is zero.
+const DefaultMaxBodySize = 1 << 20
+
+var (
+	errBadRequest = errors.New("pobjhttp: bad request")
+	errNotFound   = errors.New("pobjhttp: object not found")
+)
+
+// Handler is an http.Handler serving the objects of a registry. It can be
+// mounted under a prefix with http.StripPrefix.
+type Handler struct {
+	reg *pobj.Registry
+
+	// MaxBodySize is the maximum size in bytes of a request body. If zero,
+	// DefaultMaxBodySize is used.
+	MaxBodySize int64
+}
+
+// New returns a Handler serving the objects of registry r. If r is nil, the
+// DefaultRegistry is used.
+func New(r *pobj.Registry) *Handler {
+	if r == nil {
+		r = pobj.DefaultRegistry
+	}
+	return &Handler{reg: r}
+}
+
+// route is the target of a request, as resolved from its path.
+type route struct {
+	obj    *pobj.Object
+	id     string // object ID, empty for collection routes
+	hasId  bool
+	method string // method name, empty for action routes
+}
+
+// ServeHTTP implements http.Handler.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	rt, err := h.resolve(req.URL.EscapedPath())
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	var res any
+	status := http.StatusOK
+	switch {
+	case rt.method != "":
+		res, err = h.call(req, rt)
+	case rt.hasId:
+		res, status, err = h.item(req, rt)
+	default:
+		res, status, err = h.collection(req, rt)
+	}
+	if err != nil {
+		if errors.Is(err, pobj.ErrMissingAction) {
+			w.Header().Set("Allow", strings.Join(allowed(rt), ", "))
+		}
+		writeError(w, err)
+		return
+	}
+	if res == nil && status == http.StatusOK {
+		status = http.StatusNoContent
+	}
+	writeJSON(w, status, res)
+}
+
+// resolve walks the registry tree from its root following the segments of
+// the escaped request path p. A ':' in the last segment introduces a method
+// name if it follows an object name, or if it follows an ID and names a
+// method of the object; otherwise it is part of the ID.
+func (h *Handler) resolve(p string) (*route, error) {
+	rt := &route{}
+	p = strings.Trim(p, "/")
+	if p == "" {
+		return nil, pobj.ErrUnknownType
+	}
+	root := h.reg.Root()
+	o := root
+	segs := strings.Split(p, "/")
+	for i, s := range segs {
+		last := i == len(segs)-1
+		if pos := strings.LastIndexByte(s, ':'); last && pos != -1 {
+			name, err := url.PathUnescape(s[pos+1:])
+			if err != nil {
+				return nil, fmt.Errorf("%w: %v", errBadRequest, err)
+			}
+			head, err := url.PathUnescape(s[:pos])
+			if err != nil {
+				return nil, fmt.Errorf("%w: %v", errBadRequest, err)
+			}
+			if c := o.Child(head); c != nil {
+				rt.obj, rt.method = c, name
+				return rt, nil
+			}
+			if o != root && o.Method(name) != nil {
+				rt.obj, rt.method = o, name
+				rt.id, rt.hasId = head, true
+				return rt, nil
+			}
+		}
+		s, err := url.PathUnescape(s)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", errBadRequest, err)
+		}
+		if c := o.Child(s); c != nil {
+			o = c
+			continue
+		}
+		if !last || o == root {
+			return nil, pobj.ErrUnknownType
+		}
+		rt.id, rt.hasId = s, true
+	}
+	rt.obj = o
+	return rt, nil
+}
+
+// call invokes the method of rt with the arguments found in the request body.
+func (h *Handler) call(req *http.Request, rt *route) (any, error) {
+	if req.Method != http.MethodPost {
+		return nil, pobj.ErrMissingAction
+	}
+	m := rt.obj.Method(rt.method)
+	if m == nil {
+		return nil, pobj.ErrUnknownMethod
+	}
+	if rt.hasId && !m.RequiresInstance() {
+		return nil, fmt.Errorf("%w: %s does not take an instance", errBadRequest, m)
+	}
+	body, err := h.body(req)
+	if err != nil {
+		return nil, err
+	}
+	args, err := decodeArgs(body, m.ArgTypes())
+	if err != nil {
+		return nil, err
+	}
+	var instance any
+	if rt.hasId {
+		instance = rt.id
+	}
+	return m.Invoke(req.Context(), instance, args...)
+}
+
+// item serves the actions on a single object identified by rt.id.
+func (h *Handler) item(req *http.Request, rt *route) (any, int, error) {
+	ctx := req.Context()
+	o := rt.obj
+	switch req.Method {
+	case http.MethodGet:
+		res, err := o.ById(ctx, rt.id)
+		if err == nil && isNil(res) {
+			err = errNotFound
+		}
+		return res, http.StatusOK, err
+	case http.MethodPut, http.MethodPatch:
+		body, err := h.body(req)
+		if err != nil {
+			return nil, 0, err
+		}
+		if len(body) == 0 {
+			return nil, 0, fmt.Errorf("%w: missing request body", errBadRequest)
+		}
+		var res any
+		if req.Method == http.MethodPut {
+			res, err = o.Update(ctx, rt.id, typutil.RawJsonMessage(body))
+		} else {
+			res, err = o.Patch(ctx, rt.id, typutil.RawJsonMessage(body))
+		}
+		return res, http.StatusOK, err
+	case http.MethodDelete:
+		return nil, http.StatusNoContent, o.Delete(ctx, rt.id)
+	}
+	return nil, 0, pobj.ErrMissingAction
+}
+
+// collection serves the actions on all the objects of rt.obj.
+func (h *Handler) collection(req *http.Request, rt *route) (any, int, error) {
+	ctx := req.Context()
+	o := rt.obj
+	if o.New() == nil {
+		// intermediate object without a registered type
+		return nil, 0, pobj.ErrUnknownType
+	}
+	switch req.Method {
+	case http.MethodGet:
+		v := req.URL.Query()
+		if v.Has("count") {
+			n, err := o.Count(ctx)
+			if err != nil {
+				return nil, 0, err
+			}
+			return map[string]int64{"count": n}, http.StatusOK, nil
+		}
+		q, err := ParseQuery(v)
+		if err != nil {
+			return nil, 0, err
+		}
+		res, err := o.List(ctx, q)
+		return res, http.StatusOK, err
+	case http.MethodPost:
+		body, err := h.body(req)
+		if err != nil {
+			return nil, 0, err
+		}
+		if len(body) == 0 {
+			return nil, 0, fmt.Errorf("%w: missing request body", errBadRequest)
+		}
+		res, err := o.Create(ctx, typutil.RawJsonMessage(body))
+		return res, http.StatusCreated, err
+	case http.MethodDelete:
+		return nil, http.StatusNoContent, o.Clear(ctx)
+	}
+	return nil, 0, pobj.ErrMissingAction
+}
+
+// body reads the request body, up to MaxBodySize bytes.
+func (h *Handler) body(req *http.Request) ([]byte, error) {
+	limit := h.MaxBodySize
+	if limit == 0 {
+		limit = DefaultMaxBodySize
+	}
+	body, err := io.ReadAll(io.LimitReader(req.Body, limit+1))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errBadRequest, err)
+	}
+	if int64(len(body)) > limit {
+		return nil, fmt.Errorf("%w: request body too large", errBadRequest)
+	}
+	return body, nil
+}
+
+// decodeArgs decodes the JSON body into arguments of the given types. A
+// method taking more than one argument expects a JSON array.
+func decodeArgs(body []byte, types []reflect.Type) ([]any, error) {
+	if len(types) == 0 || len(strings.TrimSpace(string(body))) == 0 {
+		return nil, nil
+	}
+	raw := []json.RawMessage{body}
+	if len(types) > 1 {
+		raw = nil
+		if err := json.Unmarshal(body, &raw); err != nil {
+			return nil, fmt.Errorf("%w: arguments must be a JSON array: %v", errBadRequest, err)
+		}
+		if len(raw) > len(types) {
+			return nil, fmt.Errorf("%w: too many arguments", errBadRequest)
+		}
+	}
+	args := make([]any, len(raw))
+	for i, r := range raw {
+		v := reflect.New(types[i])
+		if err := json.Unmarshal(r, v.Interface()); err != nil {
+			return nil, fmt.Errorf("%w: argument %d: %v", errBadRequest, i, err)
+		}
+		args[i] = v.Elem().Interface()
+	}
+	return args, nil
+}
+
+// ParseQuery builds a pobj.ListQuery from URL query parameters:
+//
+//	cursor=X         the cursor of the page to return
+//	offset=N         the offset of the first result
+//	limit=N          the maximum number of results
+//	sort=Name,-Age   sort keys, prefixed with '-' for descending order
+//	Name=John        filter on Name equal to John
+//	Age[gte]=18      filter with an operator, for values of "in" separated by commas
+//
+// The "count" parameter is reserved. Errors wrap pobj.ErrInvalidQuery.
+func ParseQuery(v url.Values) (*pobj.ListQuery, error) {
+	q := &pobj.ListQuery{}
+	for key, values := range v {
+		val := values[len(values)-1]
+		var err error
+		switch key {
+		case "count":
+		case "cursor":
+			q.Cursor = val
+		case "offset":
+			q.Offset, err = strconv.Atoi(val)
+		case "limit":
+			q.Limit, err = strconv.Atoi(val)
+		case "sort":
+			for _, s := range strings.Split(val, ",") {
+				if s == "" {
+					continue
+				}
+				name, desc := strings.CutPrefix(s, "-")
+				q.Sort = append(q.Sort, pobj.SortField{Field: name, Desc: desc})
+			}
+		default:
+			field, op := key, pobj.OpEq
+			if pos := strings.IndexByte(key, '['); pos != -1 && strings.HasSuffix(key, "]") {
+				field, op = key[:pos], pobj.FilterOp(key[pos+1:len(key)-1])
+			}
+			for _, val := range values {
+				f := pobj.Filter{Field: field, Op: op, Value: val}
+				if op == pobj.OpIn {
+					f.Value = strings.Split(val, ",")
+				}
+				q.Filter = append(q.Filter, f)
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: bad %s: %v", pobj.ErrInvalidQuery, key, err)
+		}
+	}
+	return q, nil
+}
+
+// allowed returns the HTTP methods accepted by rt.
+func allowed(rt *route) []string {
+	if rt.method != "" {
+		return []string{http.MethodPost}
+	}
+	act := rt.obj.Actions()
+	if act == nil {
+		return nil
+	}
+	var res []string
+	add := func(c *typutil.Callable, method string) {
+		if c != nil {
+			res = append(res, method)
+		}
+	}
+	if rt.hasId {
+		add(act.Fetch, http.MethodGet)
+		add(act.Update, http.MethodPut)
+		add(act.Patch, http.MethodPatch)
+		add(act.Delete, http.MethodDelete)
+		return res
+	}
+	if act.List != nil || act.Count != nil {
+		res = append(res, http.MethodGet)
+	}
+	add(act.Create, http.MethodPost)
+	add(act.Clear, http.MethodDelete)
+	return res
+}
+
+// StatusCode returns the HTTP status code for err:
+//
+//   - the value returned by its HTTPStatus() int method, if it has one
+//   - 404 for pobj.ErrUnknownType, pobj.ErrUnknownMethod and objects not found
+//   - 403 for pobj.ErrForbidden
+//   - 405 for pobj.ErrMissingAction
+//   - 400 for invalid queries and data, missing instances and malformed requests
+//   - 500 otherwise
+func StatusCode(err error) int {
+	var se interface{ HTTPStatus() int }
+	switch {
+	case errors.As(err, &se):
+		return se.HTTPStatus()
+	case errors.Is(err, pobj.ErrUnknownType), errors.Is(err, pobj.ErrUnknownMethod), errors.Is(err, errNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, pobj.ErrForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, pobj.ErrMissingAction):
+		return http.StatusMethodNotAllowed
+	case errors.Is(err, pobj.ErrInvalidQuery), errors.Is(err, pobj.ErrValidation), errors.Is(err, pobj.ErrMissingInstance),
+		errors.Is(err, typutil.ErrMissingArgs), errors.Is(err, errBadRequest):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+// writeError writes err as a JSON object with an "error" field.
+func writeError(w http.ResponseWriter, err error) {
+	writeJSON(w, StatusCode(err), map[string]string{"error": err.Error()})
+}
+
+// writeJSON writes v as the JSON response body with the given status.
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	if status == http.StatusNoContent {
+		w.WriteHeader(status)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// isNil returns true if v is nil or a nil pointer.
+func isNil(v any) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Slice, reflect.Interface:
+		return rv.IsNil()
+	}
+	return false
+}
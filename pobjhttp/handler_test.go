@@ -0,0 +1,176 @@
+package pobjhttp_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/KarpelesLab/pobj"
+	"github.com/KarpelesLab/pobj/pobjhttp"
+	"github.com/KarpelesLab/typutil"
+)
+
+type user struct {
+	ID   string `json:"id"`
+	Name string `json:"name" pobj:"max=16"`
+}
+
+// store is a trivial in-memory user store.
+type store struct {
+	mu    sync.Mutex
+	users map[string]*user
+}
+
+func (s *store) fetch(ctx context.Context, id string) (*user, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.users[id], nil
+}
+
+func (s *store) list(ctx context.Context, q *pobj.ListQuery) ([]*user, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var res []*user
+	for _, u := range s.users {
+		if len(q.Filter) == 1 && q.Filter[0].Value != u.Name {
+			continue
+		}
+		res = append(res, u)
+	}
+	return res, nil
+}
+
+func (s *store) create(ctx context.Context, u *user) (*user, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if u.ID == "" {
+		return nil, errors.New("missing id")
+	}
+	s.users[u.ID] = u
+	return u, nil
+}
+
+func (s *store) delete(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.users, id)
+	return nil
+}
+
+func setup() *pobjhttp.Handler {
+	r := pobj.NewRegistry()
+	s := &store{users: map[string]*user{"u1": {ID: "u1", Name: "John"}}}
+	pobj.RegisterActionsIn[user](r, "api/user", &pobj.ObjectActions{
+		Fetch:  typutil.Func(s.fetch),
+		List:   typutil.Func(s.list),
+		Create: typutil.Func(s.create),
+		Delete: typutil.Func(s.delete),
+	}).SetFilterable("name")
+	r.RegisterMethod("api/user:add", func(a, b int) int { return a + b })
+	r.RegisterMethod("api/user:secret", func() int { return 42 }).Require("user.secret")
+	r.RegisterMethod("api/user:hello", func(ctx context.Context) (string, error) {
+		u, ok := pobj.InstanceFrom[user](ctx)
+		if !ok {
+			return "", errors.New("no instance")
+		}
+		return "hello " + u.Name, nil
+	}).SetRequiresInstance(true)
+	return pobjhttp.New(r)
+}
+
+func do(t *testing.T, h http.Handler, method, path, body string) (int, string, http.Header) {
+	t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w.Code, strings.TrimSpace(w.Body.String()), w.Header()
+}
+
+func TestHandler(t *testing.T) {
+	h := setup()
+
+	tests := []struct {
+		method, path, body string
+		status             int
+		want               string
+	}{
+		{"GET", "/api/user/u1", "", 200, `{"id":"u1","name":"John"}`},
+		{"GET", "/api/user/nope", "", 404, ""},
+		{"GET", "/api/user?name=John", "", 200, `[{"id":"u1","name":"John"}]`},
+		{"GET", "/api/user?email=x", "", 400, ""},
+		{"POST", "/api/user", `{"id":"u2","name":"Jane"}`, 201, `{"id":"u2","name":"Jane"}`},
+		{"GET", "/api/user/u2", "", 200, `{"id":"u2","name":"Jane"}`},
+		{"POST", "/api/user", ``, 400, ""},
+		{"POST", "/api/user", `{"name":"NoID"}`, 500, `{"error":"missing id"}`},
+		{"POST", "/api/user", `{"id":"u3","name":"Bartholomew Jr. Smith"}`, 400, `{"error":"pobj: validation failed: api/user: Name must have a length of at most 16"}`},
+		{"DELETE", "/api/user/u2", "", 204, ""},
+		{"GET", "/api/user/u2", "", 404, ""},
+		{"PUT", "/api/user/u1", `{}`, 405, ""},
+		{"DELETE", "/api/user", "", 405, ""},
+		{"POST", "/api/user:add", `[1, 2]`, 200, `3`},
+		{"POST", "/api/user:add", `[1, "x"]`, 400, ""},
+		{"GET", "/api/user:add", ``, 405, ""},
+		{"POST", "/api/user:nope", ``, 404, ""},
+		{"POST", "/api/user:secret", ``, 403, ""},
+		{"POST", "/api/user/u1:hello", ``, 200, `"hello John"`},
+		{"POST", "/api/user:hello", ``, 400, ""},
+		{"POST", "/api/user/missing:hello", ``, 400, ""},
+		{"POST", "/api/user/u1:add", `[1, 2]`, 400, ""},
+		// IDs may contain ':' unless followed by a method name
+		{"POST", "/api/user", `{"id":"urn:x","name":"Urn"}`, 201, ""},
+		{"GET", "/api/user/urn:x", "", 200, `{"id":"urn:x","name":"Urn"}`},
+		{"POST", "/api/user/urn:x:hello", ``, 200, `"hello Urn"`},
+		{"POST", "/api/user", `{"id":"u:hello","name":"Colon"}`, 201, ""},
+		{"GET", "/api/user/u%3Ahello", "", 200, `{"id":"u:hello","name":"Colon"}`},
+		{"GET", "/nope", "", 404, ""},
+		{"GET", "/api", "", 404, ""},
+		{"GET", "/", "", 404, ""},
+	}
+	for _, tt := range tests {
+		status, body, _ := do(t, h, tt.method, tt.path, tt.body)
+		if status != tt.status {
+			t.Errorf("%s %s: wrong status, got %d, want %d (%s)", tt.method, tt.path, status, tt.status, body)
+			continue
+		}
+		if tt.want != "" && body != tt.want {
+			t.Errorf("%s %s: wrong body, got %s, want %s", tt.method, tt.path, body, tt.want)
+		}
+		if status >= 400 {
+			var res struct{ Error string }
+			if err := json.Unmarshal([]byte(body), &res); err != nil || res.Error == "" {
+				t.Errorf("%s %s: bad error body %s", tt.method, tt.path, body)
+			}
+		}
+	}
+
+	_, _, hdr := do(t, h, "PUT", "/api/user/u1", `{}`)
+	if got := hdr.Get("Allow"); got != "GET, DELETE" {
+		t.Errorf("wrong Allow header, got %q", got)
+	}
+}
+
+func TestParseQuery(t *testing.T) {
+	req := httptest.NewRequest("GET", "/?limit=10&offset=5&cursor=c&sort=Name,-Age&Age[gte]=18&Tag[in]=a,b", nil)
+	q, err := pobjhttp.ParseQuery(req.URL.Query())
+	if err != nil {
+		t.Fatalf("ParseQuery failed: %v", err)
+	}
+	if q.Limit != 10 || q.Offset != 5 || q.Cursor != "c" {
+		t.Errorf("wrong pagination, got %+v", q)
+	}
+	if len(q.Sort) != 2 || q.Sort[0] != (pobj.SortField{Field: "Name"}) || q.Sort[1] != (pobj.SortField{Field: "Age", Desc: true}) {
+		t.Errorf("wrong sort, got %+v", q.Sort)
+	}
+	if len(q.Filter) != 2 {
+		t.Fatalf("wrong filters, got %+v", q.Filter)
+	}
+
+	if _, err := pobjhttp.ParseQuery(map[string][]string{"limit": {"x"}}); !errors.Is(err, pobj.ErrInvalidQuery) {
+		t.Errorf("wrong error, got %v, want %v", err, pobj.ErrInvalidQuery)
+	}
+}
@@ -0,0 +1,314 @@
+package pobjhttp
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/KarpelesLab/pobj"
+)
+
+// OpenAPIVersion is the version of the OpenAPI specification generated by
+// Handler.OpenAPI.
+const OpenAPIVersion = "3.1.0"
+
+// Document is an OpenAPI document. Only the parts needed to describe the
+// routes of a Handler are included.
+type Document struct {
+	OpenAPI    string               `json:"openapi"`
+	Info       Info                 `json:"info"`
+	Servers    []Server             `json:"servers,omitempty"`
+	Paths      map[string]*PathItem `json:"paths"`
+	Components Components           `json:"components"`
+	Tags       []Tag                `json:"tags,omitempty"`
+}
+
+// Info holds the metadata of the API.
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// Server is a base URL the API is served at.
+type Server struct {
+	URL string `json:"url"`
+}
+
+// Tag describes a registered object, used to group its operations.
+type Tag struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// PathItem holds the operations available on a path.
+type PathItem struct {
+	Get    *Operation `json:"get,omitempty"`
+	Put    *Operation `json:"put,omitempty"`
+	Post   *Operation `json:"post,omitempty"`
+	Delete *Operation `json:"delete,omitempty"`
+	Patch  *Operation `json:"patch,omitempty"`
+}
+
+// Operation describes a single route.
+type Operation struct {
+	OperationID string               `json:"operationId"`
+	Summary     string               `json:"summary,omitempty"`
+	Description string               `json:"description,omitempty"`
+	Tags        []string             `json:"tags,omitempty"`
+	Parameters  []*Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+}
+
+// Parameter describes a path or query parameter.
+type Parameter struct {
+	Name        string       `json:"name"`
+	In          string       `json:"in"`
+	Description string       `json:"description,omitempty"`
+	Required    bool         `json:"required,omitempty"`
+	Schema      *pobj.Schema `json:"schema"`
+}
+
+// RequestBody describes the JSON body of a request.
+type RequestBody struct {
+	Required bool                  `json:"required,omitempty"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+// Response describes a response.
+type Response struct {
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+// MediaType holds the schema of a body.
+type MediaType struct {
+	Schema *pobj.Schema `json:"schema"`
+}
+
+// Components holds the schemas referenced by the document.
+type Components struct {
+	Schemas map[string]*pobj.Schema `json:"schemas"`
+}
+
+const schemaPrefix = "#/components/schemas/"
+
+// errorSchema is the name of the schema of error responses, reserved in the
+// schema builder so that no object uses it.
+const errorSchema = "Error"
+
+var errTyp = reflect.TypeOf((*error)(nil)).Elem()
+
+// OpenAPI returns an OpenAPI 3.1 document describing the routes served by
+// this handler, derived from all the objects of its registry, including the
+// objects without type holding methods. The Doc of
+// objects, methods and fields is used for descriptions.
+//
+// The request and response bodies of methods are derived from the method
+// signature. Actions are described with the object type, since the
+// signature of a typutil.Callable is not available: Fetch, Create and
+// Update return an object, and List an array of objects.
+func (h *Handler) OpenAPI(info Info, servers ...Server) *Document {
+	b := h.reg.NewComponentsBuilder(errorSchema)
+	doc := &Document{
+		OpenAPI: OpenAPIVersion,
+		Info:    info,
+		Servers: servers,
+		Paths:   make(map[string]*PathItem),
+	}
+
+	for _, o := range objects(h.reg) {
+		doc.Tags = append(doc.Tags, Tag{Name: o.String(), Description: o.Doc()})
+		addActions(doc, b, o)
+		addMethods(doc, b, o)
+	}
+
+	doc.Components.Schemas = b.Defs()
+	doc.Components.Schemas[errorSchema] = &pobj.Schema{
+		Type:       "object",
+		Properties: map[string]*pobj.Schema{"error": {Type: "string"}},
+		Required:   []string{"error"},
+	}
+	return doc
+}
+
+// objects returns the objects of r that have a type or methods, in
+// depth-first order. Aliases are skipped.
+func objects(r *pobj.Registry) []*pobj.Object {
+	var res []*pobj.Object
+	for o := range r.Root().DepthFirst() {
+		if o.New() != nil || len(o.Methods()) > 0 {
+			res = append(res, o)
+		}
+	}
+	return res
+}
+
+// path returns the PathItem for p, creating it if needed.
+func (d *Document) path(p string) *PathItem {
+	item, ok := d.Paths[p]
+	if !ok {
+		item = &PathItem{}
+		d.Paths[p] = item
+	}
+	return item
+}
+
+// addActions adds the routes of the actions of o to doc.
+func addActions(doc *Document, b *pobj.SchemaBuilder, o *pobj.Object) {
+	act := o.Actions()
+	if act == nil {
+		return
+	}
+	name := o.String()
+	coll := "/" + name
+	item := coll + "/{id}"
+	obj := b.Ref(o)
+	idParam := &Parameter{Name: "id", In: "path", Required: true, Schema: &pobj.Schema{Type: "string"}}
+
+	if act.List != nil {
+		op := newOperation(o, "list", "List "+name)
+		op.Parameters = listParameters(o)
+		if act.Count != nil {
+			op.Parameters = append(op.Parameters, &Parameter{Name: "count", In: "query", Description: "Return the number of objects instead", Schema: &pobj.Schema{Type: "boolean"}})
+		}
+		op.Responses["200"] = jsonResponse("List of "+name, &pobj.Schema{Type: "array", Items: obj})
+		doc.path(coll).Get = op
+	} else if act.Count != nil {
+		op := newOperation(o, "count", "Count "+name)
+		op.Parameters = []*Parameter{{Name: "count", In: "query", Required: true, Schema: &pobj.Schema{Type: "boolean"}}}
+		op.Responses["200"] = jsonResponse("Number of "+name, &pobj.Schema{
+			Type:       "object",
+			Properties: map[string]*pobj.Schema{"count": {Type: "integer"}},
+			Required:   []string{"count"},
+		})
+		doc.path(coll).Get = op
+	}
+	if act.Create != nil {
+		op := newOperation(o, "create", "Create "+name)
+		op.RequestBody = jsonBody(obj)
+		op.Responses["201"] = jsonResponse("Created "+name, obj)
+		doc.path(coll).Post = op
+	}
+	if act.Clear != nil {
+		op := newOperation(o, "clear", "Delete all "+name)
+		op.Responses["204"] = &Response{Description: "Deleted"}
+		doc.path(coll).Delete = op
+	}
+	if act.Fetch != nil {
+		op := newOperation(o, "fetch", "Fetch "+name+" by ID")
+		op.Parameters = []*Parameter{idParam}
+		op.Responses["200"] = jsonResponse(name, obj)
+		doc.path(item).Get = op
+	}
+	if act.Update != nil {
+		op := newOperation(o, "update", "Replace "+name)
+		op.Parameters = []*Parameter{idParam}
+		op.RequestBody = jsonBody(obj)
+		op.Responses["200"] = jsonResponse("Updated "+name, obj)
+		doc.path(item).Put = op
+	}
+	if act.Patch != nil {
+		op := newOperation(o, "patch", "Partially update "+name)
+		op.Parameters = []*Parameter{idParam}
+		op.RequestBody = jsonBody(&pobj.Schema{Type: "object"})
+		op.Responses["200"] = jsonResponse("Updated "+name, obj)
+		doc.path(item).Patch = op
+	}
+	if act.Delete != nil {
+		op := newOperation(o, "delete", "Delete "+name)
+		op.Parameters = []*Parameter{idParam}
+		op.Responses["204"] = &Response{Description: "Deleted"}
+		doc.path(item).Delete = op
+	}
+}
+
+// addMethods adds the routes of the methods of o to doc.
+func addMethods(doc *Document, b *pobj.SchemaBuilder, o *pobj.Object) {
+	for _, mname := range o.Methods() {
+		m := o.Method(mname)
+		op := newOperation(o, "", "")
+		op.OperationID = m.String()
+		op.Description = m.Doc()
+		p := "/" + o.String()
+		if m.RequiresInstance() {
+			p += "/{id}"
+			op.Parameters = []*Parameter{{Name: "id", In: "path", Required: true, Schema: &pobj.Schema{Type: "string"}}}
+		}
+		p += ":" + mname
+
+		switch args := m.ArgTypes(); len(args) {
+		case 0:
+		case 1:
+			op.RequestBody = jsonBody(b.Schema(args[0]))
+		default:
+			s := &pobj.Schema{Type: "array"}
+			for _, a := range args {
+				s.PrefixItems = append(s.PrefixItems, b.Schema(a))
+			}
+			op.RequestBody = jsonBody(s)
+		}
+		if res := resultType(m.Type()); res != nil {
+			op.Responses["200"] = jsonResponse("Result", b.Schema(res))
+		} else {
+			op.Responses["204"] = &Response{Description: "No result"}
+		}
+		doc.path(p).Post = op
+	}
+}
+
+// newOperation returns an operation of o with the given id suffix and
+// summary, and the default error response.
+func newOperation(o *pobj.Object, id, summary string) *Operation {
+	return &Operation{
+		OperationID: strings.ReplaceAll(o.String(), "/", ".") + "." + id,
+		Summary:     summary,
+		Tags:        []string{o.String()},
+		Responses: map[string]*Response{
+			"default": jsonResponse("Error", &pobj.Schema{Ref: schemaPrefix + errorSchema}),
+		},
+	}
+}
+
+// listParameters returns the query parameters of a List route of o.
+func listParameters(o *pobj.Object) []*Parameter {
+	res := []*Parameter{
+		{Name: "cursor", In: "query", Description: "Cursor of the page to return", Schema: &pobj.Schema{Type: "string"}},
+		{Name: "offset", In: "query", Description: "Offset of the first result", Schema: &pobj.Schema{Type: "integer"}},
+		{Name: "limit", In: "query", Description: "Maximum number of results", Schema: &pobj.Schema{Type: "integer"}},
+	}
+	if sortable := o.SortFields(); len(sortable) > 0 {
+		res = append(res, &Parameter{
+			Name:        "sort",
+			In:          "query",
+			Description: "Comma separated sort fields, prefixed with '-' for descending order, among: " + strings.Join(sortable, ", "),
+			Schema:      &pobj.Schema{Type: "string"},
+		})
+	}
+	for _, f := range o.FilterFields() {
+		res = append(res, &Parameter{Name: f, In: "query", Description: o.FieldDoc(f), Schema: &pobj.Schema{Type: "string"}})
+	}
+	return res
+}
+
+// resultType returns the type of the result of function type fn, ignoring
+// the error result, or nil if it has none.
+func resultType(fn reflect.Type) reflect.Type {
+	if fn == nil {
+		return nil
+	}
+	for i := 0; i < fn.NumOut(); i++ {
+		if out := fn.Out(i); out != errTyp {
+			return out
+		}
+	}
+	return nil
+}
+
+func jsonBody(s *pobj.Schema) *RequestBody {
+	return &RequestBody{Required: true, Content: map[string]*MediaType{"application/json": {Schema: s}}}
+}
+
+func jsonResponse(desc string, s *pobj.Schema) *Response {
+	return &Response{Description: desc, Content: map[string]*MediaType{"application/json": {Schema: s}}}
+}
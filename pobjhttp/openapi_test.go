@@ -0,0 +1,96 @@
+package pobjhttp_test
+
+import (
+	"encoding/json"
+	"regexp"
+	"slices"
+	"testing"
+
+	"github.com/KarpelesLab/pobj"
+	"github.com/KarpelesLab/pobj/pobjhttp"
+	"github.com/KarpelesLab/typutil"
+)
+
+func TestOpenAPI(t *testing.T) {
+	doc := setup().OpenAPI(pobjhttp.Info{Title: "Test", Version: "1.0"}, pobjhttp.Server{URL: "/api"})
+
+	if doc.OpenAPI != pobjhttp.OpenAPIVersion || doc.Info.Title != "Test" {
+		t.Errorf("wrong header, got %s %+v", doc.OpenAPI, doc.Info)
+	}
+
+	coll := doc.Paths["/api/user"]
+	if coll == nil || coll.Get == nil || coll.Post == nil || coll.Delete != nil || coll.Put != nil {
+		t.Fatalf("wrong collection path, got %+v", coll)
+	}
+	if got := coll.Get.Responses["200"].Content["application/json"].Schema.Items.Ref; got != "#/components/schemas/api.user" {
+		t.Errorf("wrong list item schema, got %s", got)
+	}
+	var filter bool
+	for _, p := range coll.Get.Parameters {
+		filter = filter || p.Name == "name"
+	}
+	if !filter {
+		t.Error("missing filter parameter")
+	}
+
+	item := doc.Paths["/api/user/{id}"]
+	if item == nil || item.Get == nil || item.Delete == nil || item.Put != nil || item.Patch != nil {
+		t.Fatalf("wrong item path, got %+v", item)
+	}
+	if item.Get.OperationID != "api.user.fetch" {
+		t.Errorf("wrong operation ID, got %s", item.Get.OperationID)
+	}
+
+	add := doc.Paths["/api/user:add"]
+	if add == nil || add.Post == nil {
+		t.Fatal("missing static method path")
+	}
+	if s := add.Post.RequestBody.Content["application/json"].Schema; s.Type != "array" || len(s.PrefixItems) != 2 || s.PrefixItems[0].Type != "integer" {
+		t.Errorf("wrong method arguments schema, got %+v", s)
+	}
+	if s := add.Post.Responses["200"].Content["application/json"].Schema; s.Type != "integer" {
+		t.Errorf("wrong method result schema, got %+v", s)
+	}
+	if hello := doc.Paths["/api/user/{id}:hello"]; hello == nil || hello.Post.RequestBody != nil {
+		t.Errorf("wrong instance method path, got %+v", hello)
+	}
+
+	if doc.Components.Schemas["api.user"] == nil || doc.Components.Schemas["Error"] == nil {
+		t.Errorf("missing schemas, got %v", doc.Components.Schemas)
+	}
+	if _, err := json.Marshal(doc); err != nil {
+		t.Errorf("cannot encode document: %v", err)
+	}
+}
+
+func TestOpenAPIComponentNames(t *testing.T) {
+	type errorObj struct {
+		Code int `json:"code"`
+	}
+	r := pobj.NewRegistry()
+	pobj.RegisterIn[user](r, "admin/user")
+	pobj.RegisterActionsIn[errorObj](r, "Error", &pobj.ObjectActions{
+		Fetch: typutil.Func(func(id string) (*errorObj, error) { return &errorObj{}, nil }),
+	})
+	pobj.RegisterIn[map[string]int](r, "admin.user")
+	doc := pobjhttp.New(r).OpenAPI(pobjhttp.Info{Title: "Test", Version: "1.0"})
+
+	valid := regexp.MustCompile(`^[a-zA-Z0-9\.\-_]+$`)
+	var keys []string
+	for k := range doc.Components.Schemas {
+		if !valid.MatchString(k) {
+			t.Errorf("invalid component key %q", k)
+		}
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	if want := []string{"Error", "Error_2", "admin.user", "admin.user_2"}; !slices.Equal(keys, want) {
+		t.Errorf("wrong component keys, got %v, want %v", keys, want)
+	}
+	if s := doc.Components.Schemas["Error"]; s == nil || s.Properties["error"] == nil {
+		t.Errorf("error schema overwritten, got %+v", s)
+	}
+	if got := doc.Paths["/Error/{id}"].Get.Responses["200"].Content["application/json"].Schema; got.Ref != "#/components/schemas/Error_2" {
+		t.Errorf("wrong reference to the Error object, got %+v", got)
+	}
+}
@@ -0,0 +1,313 @@
+// Package pobjrpc serves the methods of a pobj registry over JSON-RPC 2.0.
+//
+// The JSON-RPC method is a method reference in the "object/path:methodName"
+// format, as used by pobj.RegisterMethod. Params may be an array of
+// positional arguments, or an object if the method takes a single argument.
+// An empty object is also accepted for methods without arguments.
+// Methods requiring an instance take the instance ID as first positional
+// argument, which is resolved with the object's Fetch action.
+//
+// Single requests, batches and notifications are supported, over HTTP (see
+// Server.ServeHTTP) or any stream of JSON values (see Server.ServeConn).
+package pobjrpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/KarpelesLab/pobj"
+	"github.com/KarpelesLab/typutil"
+)
+
+// Error codes defined by the JSON-RPC 2.0 specification.
+const (
+	CodeParseError     = -32700 // invalid JSON
+	CodeInvalidRequest = -32600 // the JSON is not a valid request object
+	CodeMethodNotFound = -32601 // the method does not exist
+	CodeInvalidParams  = -32602 // invalid method parameters
+	CodeInternalError  = -32603 // internal JSON-RPC error
+	CodeServerError    = -32000 // error returned by the method
+	CodeForbidden      = -32003 // the caller lacks a permission, see pobj.ErrForbidden
+)
+
+// DefaultMaxBodySize is the maximum size of an HTTP request body used when
+// Server.MaxBodySize is zero.
+const DefaultMaxBodySize = 1 << 20
+
+// Error is a JSON-RPC error object.
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    any    `json:"data,omitempty"`
+}
+
+// Error returns the message of the error.
+func (e *Error) Error() string {
+	return fmt.Sprintf("pobjrpc: %s (%d)", e.Message, e.Code)
+}
+
+// request is a JSON-RPC request or notification.
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	ID      json.RawMessage `json:"id,omitempty"` // nil for notifications
+}
+
+// response is a JSON-RPC response.
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+	ID      json.RawMessage `json:"id"`
+}
+
+// Server dispatches JSON-RPC requests to the methods of a registry.
+type Server struct {
+	reg *pobj.Registry
+
+	// MaxBodySize is the maximum size in bytes of an HTTP request body. If
+	// zero, DefaultMaxBodySize is used.
+	MaxBodySize int64
+}
+
+// New returns a Server for the methods of registry r. If r is nil, the
+// DefaultRegistry is used.
+func New(r *pobj.Registry) *Server {
+	if r == nil {
+		r = pobj.DefaultRegistry
+	}
+	return &Server{reg: r}
+}
+
+// ServeHTTP implements http.Handler. Requests must use the POST method, and
+// a request holding only notifications is answered with 204 No Content.
+func (s *Server) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	limit := s.MaxBodySize
+	if limit == 0 {
+		limit = DefaultMaxBodySize
+	}
+	body, err := io.ReadAll(io.LimitReader(req.Body, limit+1))
+	if err != nil {
+		http.Error(w, "cannot read request body", http.StatusBadRequest)
+		return
+	}
+	if int64(len(body)) > limit {
+		http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+	res := s.Handle(req.Context(), body)
+	if res == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(res)
+}
+
+// ServeConn reads JSON-RPC requests and batches from rw until EOF, and writes
+// the responses to rw as they complete, one per line. It returns nil on EOF,
+// or the error that stopped the stream. Invalid JSON is answered with a
+// parse error before returning, since the stream cannot be resynchronized.
+func (s *Server) ServeConn(ctx context.Context, rw io.ReadWriter) error {
+	dec := json.NewDecoder(rw)
+	for {
+		var msg json.RawMessage
+		if err := dec.Decode(&msg); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			var se *json.SyntaxError
+			if errors.As(err, &se) {
+				json.NewEncoder(rw).Encode(&response{JSONRPC: "2.0", Error: &Error{Code: CodeParseError, Message: err.Error()}, ID: json.RawMessage("null")})
+			}
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if res := s.Handle(ctx, msg); res != nil {
+			if _, err := rw.Write(append(res, '\n')); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// Handle processes a single JSON-RPC request or batch and returns the
+// encoded response, or nil if there is nothing to answer (notifications).
+func (s *Server) Handle(ctx context.Context, msg []byte) []byte {
+	msg = bytes.TrimSpace(msg)
+	if len(msg) > 0 && msg[0] == '[' {
+		var batch []json.RawMessage
+		if err := json.Unmarshal(msg, &batch); err != nil {
+			return encode(&response{JSONRPC: "2.0", Error: &Error{Code: CodeParseError, Message: err.Error()}, ID: json.RawMessage("null")})
+		}
+		if len(batch) == 0 {
+			return encode(&response{JSONRPC: "2.0", Error: &Error{Code: CodeInvalidRequest, Message: "empty batch"}, ID: json.RawMessage("null")})
+		}
+		var res []*response
+		for _, m := range batch {
+			if r := s.handleOne(ctx, m); r != nil {
+				res = append(res, r)
+			}
+		}
+		if len(res) == 0 {
+			return nil
+		}
+		return encode(res)
+	}
+	if r := s.handleOne(ctx, msg); r != nil {
+		return encode(r)
+	}
+	return nil
+}
+
+// handleOne processes a single request, returning nil for notifications.
+func (s *Server) handleOne(ctx context.Context, msg json.RawMessage) *response {
+	var req request
+	if err := json.Unmarshal(msg, &req); err != nil {
+		var se *json.SyntaxError
+		if errors.As(err, &se) {
+			return &response{JSONRPC: "2.0", Error: &Error{Code: CodeParseError, Message: err.Error()}, ID: json.RawMessage("null")}
+		}
+		return &response{JSONRPC: "2.0", Error: &Error{Code: CodeInvalidRequest, Message: err.Error()}, ID: json.RawMessage("null")}
+	}
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		id := req.ID
+		if id == nil {
+			id = json.RawMessage("null")
+		}
+		return &response{JSONRPC: "2.0", Error: &Error{Code: CodeInvalidRequest, Message: "invalid request"}, ID: id}
+	}
+
+	res, err := s.Call(ctx, req.Method, req.Params)
+	if req.ID == nil {
+		// notification
+		return nil
+	}
+	if err != nil {
+		return &response{JSONRPC: "2.0", Error: ToError(err), ID: req.ID}
+	}
+	// encode the result here so encoding errors can be reported with the ID
+	enc, err := json.Marshal(res)
+	if err != nil {
+		return &response{JSONRPC: "2.0", Error: &Error{Code: CodeInternalError, Message: err.Error()}, ID: req.ID}
+	}
+	return &response{JSONRPC: "2.0", Result: json.RawMessage(enc), ID: req.ID}
+}
+
+// Call invokes the method referenced by method with the given JSON params,
+// as described in the package documentation.
+func (s *Server) Call(ctx context.Context, method string, params json.RawMessage) (any, error) {
+	path, name, err := pobj.ParseMethodRef(method)
+	if err != nil {
+		return nil, err
+	}
+	o, err := s.reg.Lookup(strings.Join(path, "/"))
+	if err != nil {
+		return nil, err
+	}
+	m := o.Method(name)
+	if m == nil {
+		return nil, pobj.ErrUnknownMethod
+	}
+
+	var raw []json.RawMessage
+	params = bytes.TrimSpace(params)
+	switch {
+	case len(params) == 0, bytes.Equal(params, []byte("null")):
+	case params[0] == '[':
+		if err := json.Unmarshal(params, &raw); err != nil {
+			return nil, &Error{Code: CodeInvalidParams, Message: err.Error()}
+		}
+	case params[0] == '{':
+		var members map[string]json.RawMessage
+		if err := json.Unmarshal(params, &members); err != nil {
+			return nil, &Error{Code: CodeInvalidParams, Message: err.Error()}
+		}
+		if len(members) == 0 && len(m.ArgTypes()) == 0 {
+			// {} for a method without arguments
+			break
+		}
+		raw = []json.RawMessage{params}
+	default:
+		return nil, &Error{Code: CodeInvalidParams, Message: "params must be an array or an object"}
+	}
+
+	var instance any
+	if m.RequiresInstance() {
+		if len(raw) == 0 {
+			return nil, pobj.ErrMissingInstance
+		}
+		var id string
+		if err := json.Unmarshal(raw[0], &id); err != nil {
+			return nil, &Error{Code: CodeInvalidParams, Message: "instance ID must be a string"}
+		}
+		instance, raw = id, raw[1:]
+	}
+
+	types := m.ArgTypes()
+	if len(raw) > len(types) {
+		return nil, &Error{Code: CodeInvalidParams, Message: "too many params"}
+	}
+	args := make([]any, len(raw))
+	for i, r := range raw {
+		v := reflect.New(types[i])
+		if err := json.Unmarshal(r, v.Interface()); err != nil {
+			return nil, &Error{Code: CodeInvalidParams, Message: fmt.Sprintf("param %d: %s", i, err)}
+		}
+		args[i] = v.Elem().Interface()
+	}
+	return m.Invoke(ctx, instance, args...)
+}
+
+// ToError converts err to a JSON-RPC error object:
+//
+//   - a *Error is returned as is
+//   - errors with a JSONRPCCode() int method use that code
+//   - unknown objects and methods, and invalid method names are
+//     CodeMethodNotFound
+//   - missing arguments and instances, and invalid data are CodeInvalidParams
+//   - missing permissions are CodeForbidden
+//   - other errors are CodeServerError
+func ToError(err error) *Error {
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+	var ce interface{ JSONRPCCode() int }
+	code := CodeServerError
+	switch {
+	case errors.As(err, &ce):
+		code = ce.JSONRPCCode()
+	case errors.Is(err, pobj.ErrUnknownType), errors.Is(err, pobj.ErrUnknownMethod),
+		errors.Is(err, pobj.ErrInvalidPath), errors.Is(err, pobj.ErrInvalidMethodName):
+		code = CodeMethodNotFound
+	case errors.Is(err, pobj.ErrMissingInstance), errors.Is(err, typutil.ErrMissingArgs),
+		errors.Is(err, pobj.ErrValidation):
+		code = CodeInvalidParams
+	case errors.Is(err, pobj.ErrForbidden):
+		code = CodeForbidden
+	}
+	return &Error{Code: code, Message: err.Error()}
+}
+
+// encode returns the JSON encoding of v, which only holds responses with
+// already encoded results.
+func encode(v any) []byte {
+	res, _ := json.Marshal(v)
+	return res
+}
@@ -0,0 +1,174 @@
+package pobjrpc_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"testing/iotest"
+
+	"github.com/KarpelesLab/pobj"
+	"github.com/KarpelesLab/pobj/pobjrpc"
+	"github.com/KarpelesLab/typutil"
+)
+
+type user struct {
+	ID   string
+	Name string
+}
+
+type point struct {
+	X, Y int
+}
+
+var notified atomic.Int32
+
+func setup() *pobjrpc.Server {
+	r := pobj.NewRegistry()
+	pobj.RegisterActionsIn[user](r, "user", &pobj.ObjectActions{
+		Fetch: typutil.Func(func(ctx context.Context, id string) (*user, error) {
+			return &user{ID: id, Name: "John"}, nil
+		}),
+	})
+	r.RegisterMethod("math:add", func(a, b int) int { return a + b })
+	r.RegisterMethod("math:norm", func(p point) int { return p.X*p.X + p.Y*p.Y })
+	r.RegisterMethod("math:fail", func() error { return errors.New("failed") })
+	r.RegisterMethod("math:notify", func() { notified.Add(1) })
+	r.RegisterMethod("math:secret", func() int { return 42 }).Require("math.secret")
+	r.RegisterMethod("user:greet", func(ctx context.Context, greeting string) (string, error) {
+		u, _ := pobj.InstanceFrom[user](ctx)
+		return greeting + " " + u.Name, nil
+	}).SetRequiresInstance(true)
+	return pobjrpc.New(r)
+}
+
+func TestHandle(t *testing.T) {
+	s := setup()
+	ctx := context.Background()
+	before := notified.Load()
+
+	tests := []struct {
+		req, want string
+	}{
+		{`{"jsonrpc":"2.0","method":"math:add","params":[1,2],"id":1}`, `{"jsonrpc":"2.0","result":3,"id":1}`},
+		{`{"jsonrpc":"2.0","method":"math:norm","params":{"X":3,"Y":4},"id":"a"}`, `{"jsonrpc":"2.0","result":25,"id":"a"}`},
+		{`{"jsonrpc":"2.0","method":"user:greet","params":["u1","Hi"],"id":2}`, `{"jsonrpc":"2.0","result":"Hi John","id":2}`},
+		{`{"jsonrpc":"2.0","method":"math:notify","id":3}`, `{"jsonrpc":"2.0","result":null,"id":3}`},
+		{`{"jsonrpc":"2.0","method":"math:nope","id":4}`, `{"jsonrpc":"2.0","error":{"code":-32601,"message":"pobj: unknown method"},"id":4}`},
+		{`{"jsonrpc":"2.0","method":"nope:add","id":5}`, `{"jsonrpc":"2.0","error":{"code":-32601,"message":"pobj: unknown object type"},"id":5}`},
+		{`{"jsonrpc":"2.0","method":"math:add","params":[1,"x"],"id":6}`, `-32602`},
+		{`{"jsonrpc":"2.0","method":"math:add","params":[1,2,3],"id":7}`, `-32602`},
+		{`{"jsonrpc":"2.0","method":"user:greet","params":[],"id":8}`, `-32602`},
+		{`{"jsonrpc":"2.0","method":"math:fail","id":9}`, `{"jsonrpc":"2.0","error":{"code":-32000,"message":"failed"},"id":9}`},
+		{`{"jsonrpc":"2.0","method":"math:secret","id":9}`, `-32003`},
+		{`{"jsonrpc":"2.0","method":"math:fail","params":{},"id":9}`, `{"jsonrpc":"2.0","error":{"code":-32000,"message":"failed"},"id":9}`},
+		{`{"jsonrpc":"2.0","method":"math:fail","params":{"a":1},"id":9}`, `-32602`},
+		{`{"jsonrpc":"1.0","method":"math:add","id":10}`, `-32600`},
+		{`{"jsonrpc":"2.0","method":`, `-32700`},
+		{`[]`, `-32600`},
+		{`{"jsonrpc":"2.0","method":"math:notify"}`, ``},
+		{`[{"jsonrpc":"2.0","method":"math:notify"}]`, ``},
+		{`[{"jsonrpc":"2.0","method":"math:add","params":[1,1],"id":1},{"jsonrpc":"2.0","method":"math:notify"},{"jsonrpc":"2.0","method":"math:add","params":[2,2],"id":2}]`,
+			`[{"jsonrpc":"2.0","result":2,"id":1},{"jsonrpc":"2.0","result":4,"id":2}]`},
+	}
+	for _, tt := range tests {
+		res := string(s.Handle(ctx, []byte(tt.req)))
+		if strings.HasPrefix(tt.want, "-") {
+			var r struct{ Error *pobjrpc.Error }
+			if err := json.Unmarshal([]byte(res), &r); err != nil || r.Error == nil || tt.want != jsonInt(r.Error.Code) {
+				t.Errorf("%s: wrong error, got %s, want code %s", tt.req, res, tt.want)
+			}
+			continue
+		}
+		if res != tt.want {
+			t.Errorf("%s: wrong response, got %s, want %s", tt.req, res, tt.want)
+		}
+	}
+	if n := notified.Load() - before; n != 4 {
+		t.Errorf("notify called %d times, want 4", n)
+	}
+}
+
+func jsonInt(n int) string {
+	b, _ := json.Marshal(n)
+	return string(b)
+}
+
+func TestServeHTTP(t *testing.T) {
+	s := setup()
+
+	req := httptest.NewRequest("POST", "/", strings.NewReader(`{"jsonrpc":"2.0","method":"math:add","params":[20,22],"id":1}`))
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || w.Body.String() != `{"jsonrpc":"2.0","result":42,"id":1}` {
+		t.Errorf("wrong response, got %d %s", w.Code, w.Body)
+	}
+
+	req = httptest.NewRequest("POST", "/", strings.NewReader(`{"jsonrpc":"2.0","method":"math:notify"}`))
+	w = httptest.NewRecorder()
+	s.ServeHTTP(w, req)
+	if w.Code != http.StatusNoContent {
+		t.Errorf("wrong status for notification, got %d", w.Code)
+	}
+
+	req = httptest.NewRequest("GET", "/", nil)
+	w = httptest.NewRecorder()
+	s.ServeHTTP(w, req)
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("wrong status for GET, got %d", w.Code)
+	}
+
+	s.MaxBodySize = 8
+	req = httptest.NewRequest("POST", "/", strings.NewReader(`{"jsonrpc":"2.0"}`))
+	w = httptest.NewRecorder()
+	s.ServeHTTP(w, req)
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("wrong status for a large body, got %d", w.Code)
+	}
+
+	req = httptest.NewRequest("POST", "/", iotest.ErrReader(errors.New("connection reset")))
+	w = httptest.NewRecorder()
+	s.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("wrong status for a read error, got %d", w.Code)
+	}
+}
+
+// conn is an io.ReadWriter reading from in and writing to out.
+type conn struct {
+	in  *strings.Reader
+	out bytes.Buffer
+}
+
+func (c *conn) Read(p []byte) (int, error)  { return c.in.Read(p) }
+func (c *conn) Write(p []byte) (int, error) { return c.out.Write(p) }
+
+func TestServeConn(t *testing.T) {
+	s := setup()
+
+	c := &conn{in: strings.NewReader(`{"jsonrpc":"2.0","method":"math:add","params":[1,2],"id":1}
+{"jsonrpc":"2.0","method":"math:notify"}
+[{"jsonrpc":"2.0","method":"math:add","params":[3,4],"id":2}]`)}
+	if err := s.ServeConn(context.Background(), c); err != nil {
+		t.Fatalf("ServeConn failed: %v", err)
+	}
+	want := `{"jsonrpc":"2.0","result":3,"id":1}
+[{"jsonrpc":"2.0","result":7,"id":2}]
+`
+	if c.out.String() != want {
+		t.Errorf("wrong output, got %q, want %q", c.out.String(), want)
+	}
+
+	c = &conn{in: strings.NewReader(`{"jsonrpc":"2.0",]`)}
+	if err := s.ServeConn(context.Background(), c); err == nil {
+		t.Error("ServeConn should fail on invalid JSON")
+	}
+	if !strings.Contains(c.out.String(), "-32700") {
+		t.Errorf("expected a parse error, got %s", c.out.String())
+	}
+}
@@ -0,0 +1,421 @@
+// Package pobjts generates TypeScript type definitions and a typed client
+// for the routes served by pobjhttp.
+//
+// WriteTypes emits an interface for every registered type and the named
+// struct types they use, and WriteClient a Client class with one function
+// per action and method, grouped by object:
+//
+//	const api = new Client({ baseURL: "/api" });
+//	const user = await api.user.fetch("u1");
+//	const found = await api.user.getByEmail("john@example.com");
+//
+// Docs set with SetDoc and SetFieldDoc (or generated by pobj-docgen) are
+// carried as TSDoc comments.
+//
+// Code is generated from a pobj.Manifest, so a Generator can also be built
+// from a manifest file with FromManifest, without importing the packages
+// registering the objects.
+package pobjts
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/KarpelesLab/pobj"
+)
+
+// schemaPrefix is the prefix of $ref values in manifest schemas.
+const schemaPrefix = "#/schemas/"
+
+// Generator generates TypeScript code for the objects of a manifest.
+type Generator struct {
+	m     *pobj.Manifest
+	names map[string]string // definition name -> TypeScript type name
+}
+
+// New returns a Generator for the objects of registry r. If r is nil, the
+// DefaultRegistry is used.
+func New(r *pobj.Registry) *Generator {
+	if r == nil {
+		r = pobj.DefaultRegistry
+	}
+	return FromManifest(r.Manifest())
+}
+
+// FromManifest returns a Generator for the objects described by m, such as
+// a manifest loaded with pobj.LoadManifest.
+func FromManifest(m *pobj.Manifest) *Generator {
+	g := &Generator{m: m}
+	g.nameTypes()
+	return g
+}
+
+// nameTypes assigns a TypeScript name to every definition: the Go type name
+// if it is unique, or the PascalCase definition name otherwise.
+func (g *Generator) nameTypes() {
+	goNames := make(map[string]string) // definition name -> Go type name
+	for _, om := range g.m.Objects {
+		if om.Type != "" {
+			goNames[om.Path] = om.Type[strings.LastIndexByte(om.Type, '.')+1:]
+		}
+	}
+	count := make(map[string]int)
+	for def := range g.m.Schemas {
+		name, ok := goNames[def]
+		if !ok {
+			name = def[strings.LastIndexByte(def, '.')+1:]
+		}
+		if !isIdent(name) {
+			name = pascalCase(def)
+		}
+		goNames[def] = name
+		count[name]++
+	}
+	g.names = make(map[string]string)
+	for def := range g.m.Schemas {
+		name := goNames[def]
+		if count[name] > 1 {
+			name = pascalCase(def)
+		}
+		g.names[def] = name
+	}
+}
+
+// WriteTypes writes TypeScript declarations of the registered types to w,
+// suitable for a .d.ts file.
+func (g *Generator) WriteTypes(w io.Writer) error {
+	ew := &errWriter{w: w}
+	ew.printf("// Code generated by pobj-ts. DO NOT EDIT.\n\n")
+	ew.printf("/** Parameters of list functions. */\nexport interface ListParams {\n")
+	ew.printf("  cursor?: string;\n  offset?: number;\n  limit?: number;\n")
+	ew.printf("  /** Sort fields, prefixed with '-' for descending order. */\n  sort?: string[];\n")
+	ew.printf("  /** Filters, keyed by field name or field[op]. */\n  filter?: Record<string, string | number | boolean>;\n}\n")
+
+	defs := g.m.Schemas
+	keys := make([]string, 0, len(defs))
+	for k := range defs {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return g.names[keys[i]] < g.names[keys[j]] })
+	for _, k := range keys {
+		s := defs[k]
+		ew.printf("\n")
+		writeDoc(ew, "", s.Description)
+		if s.Type == "object" && s.Properties != nil {
+			ew.printf("export interface %s %s\n", g.names[k], g.objectType(s, ""))
+		} else {
+			ew.printf("export type %s = %s;\n", g.names[k], g.tsType(s, ""))
+		}
+	}
+	return ew.err
+}
+
+// WriteClient writes a TypeScript client for the routes served by pobjhttp
+// to w. The client imports its types from typesModule, such as "./types".
+func (g *Generator) WriteClient(w io.Writer, typesModule string) error {
+	ew := &errWriter{w: w}
+	ew.printf("// Code generated by pobj-ts. DO NOT EDIT.\n\n")
+	names := make([]string, 0, len(g.names)+1)
+	names = append(names, "ListParams")
+	for _, n := range g.names {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	ew.printf("import type { %s } from %s;\n\n", strings.Join(names, ", "), strconv.Quote(typesModule))
+	ew.printf("%s", clientRuntime)
+
+	for _, om := range g.m.Objects {
+		ew.printf("\n")
+		writeDoc(ew, "  ", om.Doc)
+		ew.printf("  readonly %s = {\n", camelCase(om.Path))
+		used := make(map[string]bool)
+		if om.Type != "" {
+			g.writeActions(ew, om, used)
+		}
+		g.writeMethods(ew, om, used)
+		ew.printf("  };\n")
+	}
+	ew.printf("}\n")
+	return ew.err
+}
+
+// writeActions writes the functions of the actions of om.
+func (g *Generator) writeActions(ew *errWriter, om *pobj.ObjectManifest, used map[string]bool) {
+	coll := strconv.Quote("/" + om.Path)
+	item := "`/" + om.Path + "/${encodeURIComponent(id)}`"
+	typ := g.names[om.Path]
+
+	fn := func(cond bool, name, doc, params, res, body string) {
+		if !cond {
+			return
+		}
+		used[name] = true
+		writeDoc(ew, "    ", doc)
+		ew.printf("    %s: (%s): Promise<%s> =>\n      this.request<%s>(%s),\n", name, params, res, res, body)
+	}
+	fn(om.HasAction("Fetch"), "fetch", "Fetches an object by ID.", "id: string", typ, `"GET", `+item)
+	fn(om.HasAction("List"), "list", "Lists objects.", "params?: ListParams", typ+"[]", `"GET", `+coll+" + listQuery(params)")
+	fn(om.HasAction("Create"), "create", "Creates an object.", "data: "+typ, typ, `"POST", `+coll+", data")
+	fn(om.HasAction("Update"), "update", "Replaces an object.", "id: string, data: "+typ, typ, `"PUT", `+item+", data")
+	fn(om.HasAction("Patch"), "patch", "Partially updates an object.", "id: string, patch: Partial<"+typ+">", typ, `"PATCH", `+item+", patch")
+	fn(om.HasAction("Delete"), "delete", "Deletes an object.", "id: string", "void", `"DELETE", `+item)
+	fn(om.HasAction("Clear"), "clear", "Deletes all objects.", "", "void", `"DELETE", `+coll)
+	if om.HasAction("Count") {
+		used["count"] = true
+		writeDoc(ew, "    ", "Counts objects.")
+		ew.printf("    count: (): Promise<number> =>\n      this.request<{ count: number }>(\"GET\", %s + \"?count\").then((r) => r.count),\n", coll)
+	}
+}
+
+// writeMethods writes the functions of the methods of om.
+func (g *Generator) writeMethods(ew *errWriter, om *pobj.ObjectManifest, used map[string]bool) {
+	for _, m := range om.Methods {
+		name := m.Name
+		fname := name
+		if used[fname] || !isIdent(fname) {
+			fname = camelCase(name) + "Method"
+		}
+		var params, args []string
+		path := strconv.Quote("/" + om.Path + ":" + name)
+		if m.RequiresInstance {
+			params = append(params, "id: string")
+			path = "`/" + om.Path + "/${encodeURIComponent(id)}:" + name + "`"
+		}
+		for i, a := range m.Args {
+			arg := fmt.Sprintf("arg%d", i)
+			params = append(params, arg+": "+g.tsType(a.Schema, "    "))
+			args = append(args, arg)
+		}
+		res := "void"
+		if rt := result(m); rt != nil {
+			res = g.tsType(rt.Schema, "    ")
+		}
+		body := ""
+		switch len(args) {
+		case 0:
+		case 1:
+			body = ", " + args[0]
+		default:
+			body = ", [" + strings.Join(args, ", ") + "]"
+		}
+		writeDoc(ew, "    ", m.Doc)
+		ew.printf("    %s: (%s): Promise<%s> =>\n      this.request<%s>(\"POST\", %s%s),\n", fname, strings.Join(params, ", "), res, res, path, body)
+	}
+}
+
+// tsType returns the TypeScript type of schema s. Nested object types are
+// indented with indent.
+func (g *Generator) tsType(s *pobj.Schema, indent string) string {
+	if s.Ref != "" {
+		return g.refName(s.Ref)
+	}
+	switch s.Type {
+	case "string":
+		return "string"
+	case "integer", "number":
+		return "number"
+	case "boolean":
+		return "boolean"
+	case "array":
+		if len(s.PrefixItems) > 0 {
+			items := make([]string, len(s.PrefixItems))
+			for i, it := range s.PrefixItems {
+				items[i] = g.tsType(it, indent)
+			}
+			return "[" + strings.Join(items, ", ") + "]"
+		}
+		item := g.tsType(s.Items, indent)
+		if strings.ContainsAny(item, " |") {
+			item = "(" + item + ")"
+		}
+		return item + "[]"
+	case "object":
+		if s.Properties != nil {
+			return g.objectType(s, indent)
+		}
+		if s.AdditionalProperties != nil {
+			return "Record<string, " + g.tsType(s.AdditionalProperties, indent) + ">"
+		}
+		return "Record<string, unknown>"
+	}
+	return "unknown"
+}
+
+// objectType returns the TypeScript object type of schema s, with its
+// properties sorted by name.
+func (g *Generator) objectType(s *pobj.Schema, indent string) string {
+	required := make(map[string]bool)
+	for _, r := range s.Required {
+		required[r] = true
+	}
+	props := make([]string, 0, len(s.Properties))
+	for p := range s.Properties {
+		props = append(props, p)
+	}
+	sort.Strings(props)
+
+	var b strings.Builder
+	b.WriteString("{\n")
+	ew := &errWriter{w: &b}
+	for _, p := range props {
+		ps := s.Properties[p]
+		writeDoc(ew, indent+"  ", ps.Description)
+		name := p
+		if !isIdent(name) {
+			name = strconv.Quote(name)
+		}
+		if ps.ReadOnly {
+			name = "readonly " + name
+		}
+		opt := "?"
+		if required[p] {
+			opt = ""
+		}
+		fmt.Fprintf(&b, "%s  %s%s: %s;\n", indent, name, opt, g.tsType(ps, indent+"  "))
+	}
+	b.WriteString(indent + "}")
+	return b.String()
+}
+
+// refName returns the TypeScript name of the definition referenced by ref.
+func (g *Generator) refName(ref string) string {
+	def := strings.TrimPrefix(ref, schemaPrefix)
+	def = strings.ReplaceAll(strings.ReplaceAll(def, "~1", "/"), "~0", "~")
+	if name, ok := g.names[def]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// clientRuntime is the part of the client that does not depend on the
+// registry.
+const clientRuntime = `/** Options of a Client. */
+export interface ClientOptions {
+  /** Base URL the pobjhttp handler is served at, such as "/api". */
+  baseURL: string;
+  /** Headers added to every request. */
+  headers?: Record<string, string>;
+  /** fetch implementation, defaults to the global fetch. */
+  fetch?: typeof fetch;
+}
+
+/** Error returned by the API. */
+export class APIError extends Error {
+  constructor(readonly status: number, message: string) {
+    super(message);
+  }
+}
+
+function listQuery(params?: ListParams): string {
+  if (!params) return "";
+  const q = new URLSearchParams();
+  if (params.cursor) q.set("cursor", params.cursor);
+  if (params.offset) q.set("offset", String(params.offset));
+  if (params.limit) q.set("limit", String(params.limit));
+  if (params.sort?.length) q.set("sort", params.sort.join(","));
+  for (const [k, v] of Object.entries(params.filter ?? {})) q.append(k, String(v));
+  const s = q.toString();
+  return s ? "?" + s : "";
+}
+
+/** Client of the API. */
+export class Client {
+  constructor(private readonly options: ClientOptions) {}
+
+  private async request<T>(method: string, path: string, body?: unknown): Promise<T> {
+    const res = await (this.options.fetch ?? fetch)(this.options.baseURL.replace(/\/$/, "") + path, {
+      method,
+      headers: { ...(body !== undefined ? { "Content-Type": "application/json" } : {}), ...this.options.headers },
+      body: body !== undefined ? JSON.stringify(body) : undefined,
+    });
+    if (!res.ok) {
+      const err = await res.json().catch(() => ({ error: res.statusText }));
+      throw new APIError(res.status, err.error);
+    }
+    if (res.status === 204) return undefined as T;
+    return res.json();
+  }
+`
+
+// result returns the result of method m, ignoring the error result, or nil
+// if it has none.
+func result(m *pobj.MethodManifest) *pobj.TypeManifest {
+	for _, r := range m.Results {
+		if r.Go != "error" {
+			return r
+		}
+	}
+	return nil
+}
+
+// writeDoc writes doc as a TSDoc comment with the given indentation.
+func writeDoc(ew *errWriter, indent, doc string) {
+	doc = strings.TrimSpace(doc)
+	if doc == "" {
+		return
+	}
+	doc = strings.ReplaceAll(doc, "*/", "*\\/")
+	lines := strings.Split(doc, "\n")
+	if len(lines) == 1 {
+		ew.printf("%s/** %s */\n", indent, doc)
+		return
+	}
+	ew.printf("%s/**\n", indent)
+	for _, l := range lines {
+		ew.printf("%s * %s\n", indent, strings.TrimRight(l, " \t"))
+	}
+	ew.printf("%s */\n", indent)
+}
+
+// pascalCase converts a path such as "org/company" to "OrgCompany".
+func pascalCase(s string) string {
+	var b strings.Builder
+	upper := true
+	for _, c := range s {
+		if !unicode.IsLetter(c) && !unicode.IsDigit(c) {
+			upper = true
+			continue
+		}
+		if upper {
+			c = unicode.ToUpper(c)
+			upper = false
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
+// camelCase converts a path such as "org/company" to "orgCompany".
+func camelCase(s string) string {
+	p := []rune(pascalCase(s))
+	if len(p) > 0 {
+		p[0] = unicode.ToLower(p[0])
+	}
+	return string(p)
+}
+
+// isIdent returns true if s is a valid JavaScript identifier.
+func isIdent(s string) bool {
+	for i, c := range s {
+		if !unicode.IsLetter(c) && c != '_' && c != '$' && (i == 0 || !unicode.IsDigit(c)) {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// errWriter is an io.Writer wrapper keeping the first write error.
+type errWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (ew *errWriter) printf(format string, args ...any) {
+	if ew.err == nil {
+		_, ew.err = fmt.Fprintf(ew.w, format, args...)
+	}
+}
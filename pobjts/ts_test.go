@@ -0,0 +1,110 @@
+package pobjts_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/KarpelesLab/pobj"
+	"github.com/KarpelesLab/pobj/pobjts"
+	"github.com/KarpelesLab/typutil"
+)
+
+type User struct {
+	ID    string   `json:"id"`
+	Name  string   `json:"name"`
+	Email string   `json:"email,omitempty"`
+	Tags  []string `json:"tags"`
+	Boss  *User    `json:"boss,omitempty"`
+}
+
+type Stats struct {
+	Count int
+}
+
+func setup() *pobjts.Generator {
+	return pobjts.New(setupRegistry())
+}
+
+func setupRegistry() *pobj.Registry {
+	r := pobj.NewRegistry()
+	pobj.RegisterActionsIn[User](r, "user", &pobj.ObjectActions{
+		Fetch:  typutil.Func(func(ctx context.Context, id string) (*User, error) { return nil, nil }),
+		List:   typutil.Func(func(ctx context.Context) ([]*User, error) { return nil, nil }),
+		Delete: typutil.Func(func(ctx context.Context, id string) error { return nil }),
+	}).SetDoc("A user").SetFieldDoc("Name", "Full name")
+	r.RegisterMethod("user:getByEmail", func(ctx context.Context, email string) (*User, error) { return nil, nil }).SetDoc("Finds a user by email")
+	r.RegisterMethod("user:rename", func(ctx context.Context, name string) error { return nil }).SetRequiresInstance(true)
+	r.RegisterMethod("admin/tools:stats", func(ctx context.Context, from, to int) (*Stats, error) { return nil, nil })
+	return r
+}
+
+func TestWriteTypes(t *testing.T) {
+	var buf bytes.Buffer
+	if err := setup().WriteTypes(&buf); err != nil {
+		t.Fatalf("WriteTypes failed: %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"/** A user */\nexport interface User {\n",
+		"  boss?: User;\n",
+		"  email?: string;\n",
+		"  /** Full name */\n  name: string;\n",
+		"  tags: string[];\n",
+		"export interface Stats {\n  Count: number;\n}\n",
+		"export interface ListParams {\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+}
+
+func TestWriteClient(t *testing.T) {
+	var buf bytes.Buffer
+	if err := setup().WriteClient(&buf, "./types"); err != nil {
+		t.Fatalf("WriteClient failed: %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		`import type { ListParams, Stats, User } from "./types";`,
+		"  /** A user */\n  readonly user = {\n",
+		"    fetch: (id: string): Promise<User> =>\n      this.request<User>(\"GET\", `/user/${encodeURIComponent(id)}`),\n",
+		"    list: (params?: ListParams): Promise<User[]> =>\n",
+		"    delete: (id: string): Promise<void> =>\n",
+		"    /** Finds a user by email */\n    getByEmail: (arg0: string): Promise<User> =>\n      this.request<User>(\"POST\", \"/user:getByEmail\", arg0),\n",
+		"    rename: (id: string, arg0: string): Promise<void> =>\n      this.request<void>(\"POST\", `/user/${encodeURIComponent(id)}:rename`, arg0),\n",
+		"  readonly adminTools = {\n    stats: (arg0: number, arg1: number): Promise<Stats> =>\n      this.request<Stats>(\"POST\", \"/admin/tools:stats\", [arg0, arg1]),\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "create:") {
+		t.Error("client should not have a create function")
+	}
+}
+
+func TestFromManifest(t *testing.T) {
+	r := setupRegistry()
+	data, err := json.Marshal(r.Manifest())
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := pobj.LoadManifest(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var want, got bytes.Buffer
+	pobjts.New(r).WriteTypes(&want)
+	pobjts.New(r).WriteClient(&want, "./types")
+	g := pobjts.FromManifest(m)
+	g.WriteTypes(&got)
+	g.WriteClient(&got, "./types")
+	if got.String() != want.String() {
+		t.Errorf("output from a loaded manifest differs:\n%s", got.String())
+	}
+}
@@ -1,31 +1,38 @@
 package pobj
 
 import (
-	"fmt"
 	"reflect"
 	"strings"
 
 	"github.com/KarpelesLab/typutil"
 )
 
-// Register adds a type to the registry with the given name.
+// Register adds a type to the DefaultRegistry with the given name.
 // The type T is determined by the generic parameter.
 // Name can be a path using '/' as separator for nested object registration.
-// Returns the registered Object for further configuration.
-// Panics if the name is already registered with a different type.
+// Returns the registered Object for further configuration. The metadata of
+// the exported fields of struct types is populated from their pobj tag (see
+// Field.Required).
+// Panics if the name is already registered with a different type, is not a
+// valid path (see ParsePath), or if a field has an invalid pobj tag.
 func Register[T any](name string) *Object {
-	mu.Lock()
-	defer mu.Unlock()
-	o := lookup(name, true)
-	if o.typ != nil {
-		panic(fmt.Sprintf("multiple registrations for type %s (%T), existing = %+v", name, (*T)(nil), o))
-	}
-	o.typ = reflect.TypeOf((*T)(nil))
-	for o.typ.Kind() == reflect.Pointer {
-		o.typ = o.typ.Elem()
-	}
-	typLookup[o.typ] = o
-	return o
+	return RegisterIn[T](DefaultRegistry, name)
+}
+
+// RegisterIn is like Register but adds the type to registry r.
+func RegisterIn[T any](r *Registry, name string) *Object {
+	return must(TryRegisterIn[T](r, name))
+}
+
+// TryRegister is like Register but returns a *RegistrationError instead of
+// panicking if the registration fails.
+func TryRegister[T any](name string) (*Object, error) {
+	return TryRegisterIn[T](DefaultRegistry, name)
+}
+
+// TryRegisterIn is like TryRegister but adds the type to registry r.
+func TryRegisterIn[T any](r *Registry, name string) (*Object, error) {
+	return r.register(name, typeFor[T](), nil)
 }
 
 // RegisterStatic adds a static method to an object.
@@ -39,7 +46,8 @@ func RegisterStatic(name string, fn any) {
 	RegisterMethod(name, fn)
 }
 
-// RegisterMethod adds a method to an object and returns the Method for further configuration.
+// RegisterMethod adds a method to an object of the DefaultRegistry and returns
+// the Method for further configuration.
 // The name must be in the format "object/path:methodName" where:
 // - "object/path" is the registered object's path
 // - "methodName" is the name of the method
@@ -52,52 +60,233 @@ func RegisterStatic(name string, fn any) {
 //	    SetDoc("Fetch a user by their email address").
 //	    SetRequiresInstance(false)
 func RegisterMethod(name string, fn any) *Method {
-	pos := strings.IndexByte(name, ':')
-	if pos == -1 {
-		panic(fmt.Sprintf("invalid name %s for method", name))
-	}
+	return DefaultRegistry.RegisterMethod(name, fn)
+}
 
-	callable := typutil.Func(fn)
-	if callable == nil {
-		panic(fmt.Sprintf("invalid method %T", fn))
-	}
+// RegisterMethod adds a method to an object of this registry. See the
+// package-level RegisterMethod for details.
+func (r *Registry) RegisterMethod(name string, fn any) *Method {
+	return must(r.TryRegisterMethod(name, fn))
+}
 
-	mu.Lock()
-	defer mu.Unlock()
-	o := lookup(name[:pos], true)
-	methodName := name[pos+1:]
+// TryRegisterMethod is like RegisterMethod but adds the method to the
+// DefaultRegistry and returns a *RegistrationError instead of panicking if the
+// name format is invalid or fn is not callable.
+func TryRegisterMethod(name string, fn any) (*Method, error) {
+	return DefaultRegistry.TryRegisterMethod(name, fn)
+}
 
-	if o.methods == nil {
-		o.methods = make(map[string]*Method)
+// TryRegisterMethod is like RegisterMethod but returns a *RegistrationError
+// instead of panicking.
+func (r *Registry) TryRegisterMethod(name string, fn any) (*Method, error) {
+	pa, methodName, err := ParseMethodRef(name)
+	if err != nil {
+		return nil, &RegistrationError{Path: name, Err: err}
 	}
 
+	callable, err := toCallable(fn)
+	if err != nil {
+		return nil, &RegistrationError{Path: name, Type: reflect.TypeOf(fn), Err: err}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	o := r.lookup(pa, true)
+
 	m := &Method{
 		callable: callable,
+		fnType:   reflect.TypeOf(fn),
 		object:   o,
 		name:     methodName,
 	}
-	o.methods[methodName] = m
-	return m
+	o.methods.set(methodName, m)
+	return m, nil
+}
+
+// toCallable converts fn to a typutil.Callable, returning ErrNotCallable
+// instead of panicking if fn is not a suitable function.
+func toCallable(fn any) (c *typutil.Callable, err error) {
+	if fn == nil || reflect.TypeOf(fn).Kind() != reflect.Func {
+		return nil, ErrNotCallable
+	}
+	defer func() {
+		if recover() != nil {
+			c, err = nil, ErrNotCallable
+		}
+	}()
+	c = typutil.Func(fn)
+	if c == nil {
+		return nil, ErrNotCallable
+	}
+	return c, nil
 }
 
-// RegisterActions registers a type with associated actions for API operations.
+// must returns v, or panics if err is not nil.
+func must[T any](v T, err error) T {
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+// RegisterActions registers a type with associated actions for API operations
+// in the DefaultRegistry.
 // The actions include common operations like Fetch, List, Clear, and Create.
 // Similar to Register, but also associates the ObjectActions with the registered type.
 // Intended for implementing REST-like operations on the registered type.
 // Returns the registered Object for further configuration.
-// Panics if the name is already registered with a different type.
+// Panics if the name is already registered with a different type or is not a
+// valid path (see ParsePath).
 func RegisterActions[T any](name string, actions *ObjectActions) *Object {
-	mu.Lock()
-	defer mu.Unlock()
-	o := lookup(name, true)
-	if o.typ != nil {
-		panic(fmt.Sprintf("multiple registrations for type %s (%T), existing = %+v", name, (*T)(nil), o))
-	}
-	o.typ = reflect.TypeOf((*T)(nil))
-	for o.typ.Kind() == reflect.Pointer {
-		o.typ = o.typ.Elem()
-	}
-	typLookup[o.typ] = o
-	o.Action = actions
+	return RegisterActionsIn[T](DefaultRegistry, name, actions)
+}
+
+// RegisterActionsIn is like RegisterActions but adds the type to registry r.
+func RegisterActionsIn[T any](r *Registry, name string, actions *ObjectActions) *Object {
+	return must(TryRegisterActionsIn[T](r, name, actions))
+}
+
+// TryRegisterActions is like RegisterActions but returns a *RegistrationError
+// instead of panicking if the registration fails.
+func TryRegisterActions[T any](name string, actions *ObjectActions) (*Object, error) {
+	return TryRegisterActionsIn[T](DefaultRegistry, name, actions)
+}
+
+// TryRegisterActionsIn is like TryRegisterActions but adds the type to registry r.
+func TryRegisterActionsIn[T any](r *Registry, name string, actions *ObjectActions) (*Object, error) {
+	return r.register(name, typeFor[T](), actions)
+}
+
+// register adds typ at path name, with optional actions.
+func (r *Registry) register(name string, typ reflect.Type, actions *ObjectActions) (*Object, error) {
+	pa, err := ParsePath(name)
+	if err != nil {
+		return nil, &RegistrationError{Path: name, Type: typ, Err: err}
+	}
+	fields, err := typeFields(typ)
+	if err != nil {
+		return nil, &RegistrationError{Path: name, Type: typ, Err: err}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if o := r.lookup(pa, false); o != nil {
+		if existing := o.rtype(); existing != nil {
+			return nil, &RegistrationError{Path: name, Type: typ, Existing: existing, Err: ErrDuplicatePath}
+		}
+	}
+	o := r.create(pa, actions)
+	o.state.Store(&objectState{typ: typ, actions: actions})
+	o.setFields(fields)
+	r.addType(typ, o)
+	return o, nil
+}
+
+// Replace registers type T with the given actions at name in the
+// DefaultRegistry, atomically swapping the type and actions of the object if
+// name is already registered. Children, methods and documentation of an
+// existing object are kept.
+// Returns the registered Object. Panics if name is not a valid path.
+func Replace[T any](name string, actions *ObjectActions) *Object {
+	return ReplaceIn[T](DefaultRegistry, name, actions)
+}
+
+// ReplaceIn is like Replace but operates on registry r.
+func ReplaceIn[T any](r *Registry, name string, actions *ObjectActions) *Object {
+	return r.replace(name, typeFor[T](), actions)
+}
+
+// replace sets typ and actions at path name, regardless of any previous registration.
+func (r *Registry) replace(name string, typ reflect.Type, actions *ObjectActions) *Object {
+	pa, err := ParsePath(name)
+	if err != nil {
+		panic(&RegistrationError{Path: name, Type: typ, Err: err})
+	}
+	fields, err := typeFields(typ)
+	if err != nil {
+		panic(&RegistrationError{Path: name, Type: typ, Err: err})
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	o := r.create(pa, actions)
+	if old := o.rtype(); old != nil {
+		r.removeType(old, o)
+	}
+	o.state.Store(&objectState{typ: typ, actions: actions})
+	o.setFields(fields)
+	r.addType(typ, o)
 	return o
 }
+
+// Unregister removes the registration at path name from the DefaultRegistry.
+// See Registry.Unregister.
+func Unregister(name string) bool {
+	return DefaultRegistry.Unregister(name)
+}
+
+// Unregister removes the type, actions, methods, field metadata and aliases
+// registered at path name. Child objects, interceptors (see Object.Use) and
+// permissions (see Object.Require) are kept, so that they apply to a later
+// registration at the same path. An object left with none of these is
+// removed from the tree, along with any intermediate object left empty.
+// If name is an alias (see Object.Alias), only the alias is removed.
+// Returns false if nothing was registered at name.
+func (r *Registry) Unregister(name string) bool {
+	pa, err := ParsePath(name)
+	if err != nil {
+		return false
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	o := r.lookup(pa, false)
+	if o == nil {
+		return false
+	}
+	if alias := strings.Join(pa, "/"); o.isAlias(alias) {
+		r.removeAlias(o, alias)
+		return true
+	}
+	typ := o.rtype()
+	if typ == nil && len(o.methods.load()) == 0 {
+		return false
+	}
+	if typ != nil {
+		r.removeType(typ, o)
+	}
+	for alias := range o.aliases.load() {
+		r.removeAlias(o, alias)
+	}
+	o.state.Store(nil)
+	o.methods.reset()
+	o.fields.reset()
+	r.prune(o)
+	return true
+}
+
+// UnregisterMethod removes a method from the DefaultRegistry. See
+// Registry.UnregisterMethod.
+func UnregisterMethod(name string) bool {
+	return DefaultRegistry.UnregisterMethod(name)
+}
+
+// UnregisterMethod removes a method registered with RegisterMethod. The name
+// uses the same "object/path:methodName" format. If the object is left empty,
+// it is removed from the tree along with any empty intermediate object.
+// Returns false if no such method exists.
+func (r *Registry) UnregisterMethod(name string) bool {
+	pa, methodName, err := ParseMethodRef(name)
+	if err != nil {
+		return false
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	o := r.lookup(pa, false)
+	if o == nil {
+		return false
+	}
+	if _, ok := o.methods.get(methodName); !ok {
+		return false
+	}
+	o.methods.delete(methodName)
+	r.prune(o)
+	return true
+}
@@ -2,6 +2,8 @@ package pobj_test
 
 import (
 	"context"
+	"errors"
+	"reflect"
 	"testing"
 
 	"github.com/KarpelesLab/pobj"
@@ -387,3 +389,199 @@ func TestNilMethodSafety(t *testing.T) {
 		t.Error("SetRequiresInstance() on nil should return nil")
 	}
 }
+
+func TestUnregister(t *testing.T) {
+	r := pobj.NewRegistry()
+	pobj.RegisterIn[TestPerson](r, "plugin/deep/person")
+	r.RegisterMethod("plugin/deep/person:hello", func() {})
+	pobj.RegisterIn[TestCompany](r, "plugin/company")
+
+	if !r.Unregister("plugin/deep/person") {
+		t.Fatal("Unregister returned false for registered path")
+	}
+	if r.Get("plugin/deep/person") != nil {
+		t.Error("Object still present after Unregister")
+	}
+	if r.Get("plugin/deep") != nil {
+		t.Error("Empty intermediate object was not pruned")
+	}
+	if r.Get("plugin/company") == nil {
+		t.Error("Sibling object was removed")
+	}
+	if pobj.GetByTypeIn[TestPerson](r) != nil {
+		t.Error("Type index still references unregistered object")
+	}
+	if r.Unregister("plugin/deep/person") {
+		t.Error("Unregister returned true for already removed path")
+	}
+
+	// Registering again must not panic
+	pobj.RegisterIn[TestPerson](r, "plugin/deep/person")
+
+	// An object with children is kept, but loses its registration
+	pobj.RegisterIn[TestCompany](r, "plugin/deep")
+	if !r.Unregister("plugin/deep") {
+		t.Fatal("Unregister returned false for registered path")
+	}
+	o := r.Get("plugin/deep")
+	if o == nil {
+		t.Fatal("Object with children should be kept")
+	}
+	if o.New() != nil {
+		t.Error("Unregistered object should have no type")
+	}
+	if r.Get("plugin/deep/person") == nil {
+		t.Error("Child object was removed")
+	}
+
+	// An object with permissions is kept for a later registration
+	pobj.RegisterIn[TestCompany](r, "guarded").Require("admin")
+	if !r.Unregister("guarded") {
+		t.Fatal("Unregister returned false for registered path")
+	}
+	if o := r.Get("guarded"); o == nil || o.HasType() || len(o.Permissions("")) != 1 {
+		t.Error("Object with permissions should be kept without type")
+	}
+}
+
+func TestUnregisterMethod(t *testing.T) {
+	r := pobj.NewRegistry()
+	r.RegisterMethod("tools/str:upper", func() {})
+	r.RegisterMethod("tools/str:lower", func() {})
+
+	if !r.UnregisterMethod("tools/str:upper") {
+		t.Fatal("UnregisterMethod returned false for registered method")
+	}
+	if r.Get("tools/str").Method("upper") != nil {
+		t.Error("Method still present after UnregisterMethod")
+	}
+	if r.UnregisterMethod("tools/str:upper") {
+		t.Error("UnregisterMethod returned true for removed method")
+	}
+	if r.UnregisterMethod("no-colon") {
+		t.Error("UnregisterMethod returned true for invalid name")
+	}
+
+	if !r.UnregisterMethod("tools/str:lower") {
+		t.Fatal("UnregisterMethod returned false for registered method")
+	}
+	if r.Get("tools") != nil {
+		t.Error("Empty objects were not pruned")
+	}
+}
+
+func TestReplace(t *testing.T) {
+	r := pobj.NewRegistry()
+	ctx := context.Background()
+
+	obj := pobj.RegisterIn[TestPerson](r, "reload").SetDoc("reloadable")
+	r.RegisterMethod("reload:ping", func() string { return "pong" })
+
+	replaced := pobj.ReplaceIn[TestCompany](r, "reload", &pobj.ObjectActions{
+		Fetch: typutil.Func(func(ctx context.Context, id string) (*TestCompany, error) {
+			return &TestCompany{ID: id}, nil
+		}),
+	})
+	if replaced != obj {
+		t.Error("Replace should keep the same Object")
+	}
+	if _, ok := obj.New().(*TestCompany); !ok {
+		t.Errorf("Wrong type after Replace, got %T", obj.New())
+	}
+	if pobj.GetByTypeIn[TestPerson](r) != nil {
+		t.Error("Old type still in type index")
+	}
+	if pobj.GetByTypeIn[TestCompany](r) != obj {
+		t.Error("New type not in type index")
+	}
+	if obj.Doc() != "reloadable" || obj.Method("ping") == nil {
+		t.Error("Replace should keep doc and methods")
+	}
+	if _, err := pobj.ByIdIn[TestCompany](ctx, r, "c1"); err != nil {
+		t.Errorf("ByIdIn failed after Replace: %v", err)
+	}
+
+	// Replace on a new path registers it
+	pobj.ReplaceIn[TestPerson](r, "fresh", nil)
+	if pobj.GetByTypeIn[TestPerson](r) != r.Get("fresh") {
+		t.Error("Replace on new path should register it")
+	}
+}
+
+func TestActionField(t *testing.T) {
+	r := pobj.NewRegistry()
+	ctx := context.Background()
+	actions := &pobj.ObjectActions{}
+
+	obj := pobj.RegisterActionsIn[TestPerson](r, "legacy", actions)
+	if obj.Action != actions || obj.Actions() != actions {
+		t.Error("Action not set at registration")
+	}
+
+	// objects holding methods or children are claimed by the registration
+	r.RegisterMethod("early:ping", func() {})
+	pobj.RegisterIn[TestCompany](r, "parent/child")
+	for _, path := range []string{"early", "parent"} {
+		if o := pobj.RegisterActionsIn[TestPerson](r, path, actions); o.Action != actions || o.Actions() != actions {
+			t.Errorf("Action not set when registering existing object %s", path)
+		}
+	}
+	pobj.ReplaceIn[TestPerson](r, "legacy", nil)
+	if obj.Action != actions || obj.Actions() != nil {
+		t.Error("Replace should only change Actions")
+	}
+
+	// direct assignments are still honored
+	obj.Action = &pobj.ObjectActions{
+		Fetch: typutil.Func(func(ctx context.Context, id string) (*TestPerson, error) {
+			return &TestPerson{ID: id}, nil
+		}),
+	}
+	if p, err := pobj.ByIdIn[TestPerson](ctx, r, "p1"); err != nil || p.ID != "p1" {
+		t.Errorf("ById with assigned actions failed, got %v, %v", p, err)
+	}
+}
+
+func TestTryRegister(t *testing.T) {
+	r := pobj.NewRegistry()
+
+	if _, err := pobj.TryRegisterIn[TestPerson](r, "dup"); err != nil {
+		t.Fatalf("TryRegisterIn failed: %v", err)
+	}
+
+	_, err := pobj.TryRegisterActionsIn[TestCompany](r, "dup", nil)
+	if !errors.Is(err, pobj.ErrDuplicatePath) {
+		t.Fatalf("Wrong error, got %v, want %v", err, pobj.ErrDuplicatePath)
+	}
+	var regErr *pobj.RegistrationError
+	if !errors.As(err, &regErr) {
+		t.Fatalf("Expected *RegistrationError, got %T", err)
+	}
+	if regErr.Path != "dup" {
+		t.Errorf("Wrong path, got %s, want dup", regErr.Path)
+	}
+	if regErr.Type != reflect.TypeOf(TestCompany{}) {
+		t.Errorf("Wrong type, got %v", regErr.Type)
+	}
+	if regErr.Existing != reflect.TypeOf(TestPerson{}) {
+		t.Errorf("Wrong existing type, got %v", regErr.Existing)
+	}
+
+	if _, err := r.TryRegisterMethod("no-colon", func() {}); !errors.Is(err, pobj.ErrInvalidMethodName) {
+		t.Errorf("Wrong error, got %v, want %v", err, pobj.ErrInvalidMethodName)
+	}
+	if _, err := r.TryRegisterMethod("dup:bad", "not a function"); !errors.Is(err, pobj.ErrNotCallable) {
+		t.Errorf("Wrong error, got %v, want %v", err, pobj.ErrNotCallable)
+	}
+	if _, err := r.TryRegisterMethod("dup:bad", func(context.Context, context.Context) {}); !errors.Is(err, pobj.ErrNotCallable) {
+		t.Errorf("Wrong error, got %v, want %v", err, pobj.ErrNotCallable)
+	}
+	if r.Get("dup").Method("bad") != nil {
+		t.Error("Failed registration should not add a method")
+	}
+
+	m, err := r.TryRegisterMethod("dup:good", func() {})
+	if err != nil || m == nil {
+		t.Fatalf("TryRegisterMethod failed: %v", err)
+	}
+}
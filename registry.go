@@ -0,0 +1,191 @@
+package pobj
+
+import (
+	"reflect"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// Registry is an independent object registry. Each Registry carries its own
+// object tree, type index and lock, so several registries can coexist in the
+// same process (per tenant, per test, per plugin, ...).
+//
+// The package-level functions such as Register, Get and GetByType operate on
+// DefaultRegistry.
+//
+// Reads (Get, GetByType, All and navigation through Object) never take a lock;
+// registrations are serialized by mu and publish new immutable snapshots.
+type Registry struct {
+	root      *Object                         // top-level object in the hierarchy
+	typLookup cowMap[reflect.Type, []*Object] // objects by their reflected type, in registration order
+	mu        sync.Mutex                      // serializes modifications of the tree and typLookup
+
+	authorizer atomic.Pointer[Authorizer] // checks the permissions required by objects and methods
+
+	typeIDs    cowMap[string, uint64] // type IDs by object path, see Object.TypeID
+	typePaths  cowMap[uint64, string] // object paths by type ID
+	lastTypeID uint64                 // highest type ID in use, protected by mu
+}
+
+// DefaultRegistry is the registry used by the package-level functions.
+var DefaultRegistry = NewRegistry()
+
+// NewRegistry returns a new, empty Registry.
+func NewRegistry() *Registry {
+	r := &Registry{}
+	r.root = &Object{reg: r}
+	return r
+}
+
+// lookup finds an Object by its path segments in the hierarchy, as returned
+// by ParsePath.
+// If create is true, it will create missing objects along the path.
+// Caller must hold r.mu if create is true. Lookups without create are lock-free.
+func (r *Registry) lookup(pa []string, create bool) *Object {
+	c := r.root
+
+	for _, s := range pa {
+		if x, ok := c.children.get(s); ok {
+			c = x
+			continue
+		}
+		if !create {
+			return nil
+		}
+		x := &Object{parent: c, name: s, reg: r}
+		c.children.set(s, x)
+		c = x
+	}
+	return c
+}
+
+// create returns the object at path pa, creating it and its parents if
+// needed, for a registration with the given actions. The Action field is set
+// if the object has no type yet, which for a created object happens before
+// it is published. Caller must hold r.mu, and store the state afterwards.
+func (r *Registry) create(pa []string, actions *ObjectActions) *Object {
+	if o := r.lookup(pa, false); o != nil {
+		if o.rtype() == nil {
+			// claimed by this registration, such as an object holding
+			// methods or children
+			o.Action, o.action0 = actions, actions
+		}
+		return o
+	}
+	p := r.lookup(pa[:len(pa)-1], true)
+	o := &Object{parent: p, name: pa[len(pa)-1], reg: r, Action: actions, action0: actions}
+	p.children.set(o.name, o)
+	return o
+}
+
+// prune removes o from its parent if it is empty, then repeats the process
+// with the parent. This undoes the intermediate nodes created by lookup.
+// Caller must hold r.mu.
+func (r *Registry) prune(o *Object) {
+	for o != nil && o.parent != nil && o.isEmpty() {
+		if cur, ok := o.parent.children.get(o.name); ok && cur == o {
+			o.parent.children.delete(o.name)
+		}
+		o = o.parent
+	}
+}
+
+// Root returns the root object holder, which is the top-level object
+// in the hierarchical registry.
+func (r *Registry) Root() *Object {
+	return r.root
+}
+
+// Get returns the Object matching the given name, or nil if no such object
+// exists or name is not a valid path.
+// The name can be a path using '/' as separator for nested objects.
+func (r *Registry) Get(name string) *Object {
+	o, _ := r.Lookup(name)
+	return o
+}
+
+// Lookup is like Get but returns an error wrapping ErrInvalidPath if name is
+// not a valid path (see ParsePath), or ErrUnknownType if no object exists at
+// that path.
+func (r *Registry) Lookup(name string) (*Object, error) {
+	pa, err := ParsePath(name)
+	if err != nil {
+		return nil, err
+	}
+	o := r.lookup(pa, false)
+	if o == nil {
+		return nil, ErrUnknownType
+	}
+	return o, nil
+}
+
+// GetByType returns the Object registered for the given type. Pointer types
+// are unwrapped to their underlying type.
+// If the type was registered at several paths, the most recent registration
+// is returned. Use Object.Alias to expose a single registration under several
+// paths, and GetAllByType to retrieve every registration.
+// Returns nil if the type is not registered.
+func (r *Registry) GetByType(t reflect.Type) *Object {
+	list := r.GetAllByType(t)
+	if len(list) == 0 {
+		return nil
+	}
+	return list[len(list)-1]
+}
+
+// GetAllByType returns every Object registered for the given type, in
+// registration order. Aliases are not included as they share their Object.
+// The returned slice must not be modified.
+func (r *Registry) GetAllByType(t reflect.Type) []*Object {
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	list, _ := r.typLookup.get(t)
+	return list
+}
+
+// All returns all registered Objects that have an associated type, sorted
+// by path. This can be used for introspection and debugging.
+func (r *Registry) All() []*Object {
+	m := r.typLookup.load()
+	res := make([]*Object, 0, len(m))
+	for _, list := range m {
+		res = append(res, list...)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].String() < res[j].String() })
+	return res
+}
+
+// addType records o as a registration of typ. Caller must hold r.mu.
+func (r *Registry) addType(typ reflect.Type, o *Object) {
+	list, _ := r.typLookup.get(typ)
+	n := make([]*Object, 0, len(list)+1)
+	n = append(append(n, list...), o)
+	r.typLookup.set(typ, n)
+}
+
+// removeType removes o from the registrations of typ. Caller must hold r.mu.
+func (r *Registry) removeType(typ reflect.Type, o *Object) {
+	list, _ := r.typLookup.get(typ)
+	n := make([]*Object, 0, len(list))
+	for _, x := range list {
+		if x != o {
+			n = append(n, x)
+		}
+	}
+	if len(n) == 0 {
+		r.typLookup.delete(typ)
+		return
+	}
+	r.typLookup.set(typ, n)
+}
+
+// typeFor returns the reflect.Type for T, with any pointer levels removed.
+func typeFor[T any]() reflect.Type {
+	t := reflect.TypeOf((*T)(nil))
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
@@ -0,0 +1,142 @@
+package pobj_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/KarpelesLab/pobj"
+	"github.com/KarpelesLab/typutil"
+)
+
+func TestRegistryIsolation(t *testing.T) {
+	r1 := pobj.NewRegistry()
+	r2 := pobj.NewRegistry()
+
+	// The same path can be registered in two registries without panic
+	o1 := pobj.RegisterIn[TestPerson](r1, "user")
+	o2 := pobj.RegisterIn[TestCompany](r2, "user")
+
+	if r1.Get("user") != o1 {
+		t.Error("r1.Get returned wrong object")
+	}
+	if r2.Get("user") != o2 {
+		t.Error("r2.Get returned wrong object")
+	}
+
+	if pobj.GetByTypeIn[TestPerson](r1) != o1 {
+		t.Error("GetByTypeIn[TestPerson](r1) returned wrong object")
+	}
+	if pobj.GetByTypeIn[TestPerson](r2) != nil {
+		t.Error("GetByTypeIn[TestPerson](r2) should return nil")
+	}
+
+	if len(r1.All()) != 1 || len(r2.All()) != 1 {
+		t.Errorf("Wrong number of objects, got %d and %d, want 1 and 1", len(r1.All()), len(r2.All()))
+	}
+
+	// Registrations in a custom registry must not leak in the default one
+	if pobj.Get("user") != nil {
+		t.Error("Registration leaked into DefaultRegistry")
+	}
+
+	if o1.String() != "user" {
+		t.Errorf("Wrong string, got %s, want %s", o1.String(), "user")
+	}
+	if r1.Root().Child("user") != o1 {
+		t.Error("Root().Child returned wrong object")
+	}
+}
+
+func TestRegistryMethodAndById(t *testing.T) {
+	r := pobj.NewRegistry()
+	ctx := context.Background()
+
+	pobj.RegisterActionsIn[TestCompany](r, "company", &pobj.ObjectActions{
+		Fetch: typutil.Func(func(ctx context.Context, id string) (*TestCompany, error) {
+			return &TestCompany{ID: id}, nil
+		}),
+	})
+	m := r.RegisterMethod("company/sub:hello", func() string { return "hello" })
+	if m.String() != "company/sub:hello" {
+		t.Errorf("Wrong method string, got %s", m.String())
+	}
+	if r.Get("company/sub").Method("hello") != m {
+		t.Error("Method not found in registry")
+	}
+
+	c, err := pobj.ByIdIn[TestCompany](ctx, r, "c-1")
+	if err != nil {
+		t.Fatalf("ByIdIn failed: %v", err)
+	}
+	if c.ID != "c-1" {
+		t.Errorf("Wrong ID, got %s, want c-1", c.ID)
+	}
+
+	if _, err := pobj.ByIdIn[TestPerson](ctx, r, "x"); err != pobj.ErrUnknownType {
+		t.Errorf("Wrong error, got %v, want %v", err, pobj.ErrUnknownType)
+	}
+}
+
+// TestConcurrentAccess exercises reads of the Object API while registrations
+// happen in parallel. Run with -race to detect unsynchronized accesses.
+func TestConcurrentAccess(t *testing.T) {
+	r := pobj.NewRegistry()
+	obj := pobj.RegisterIn[TestPerson](r, "concurrent")
+
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				o := r.Get("concurrent")
+				o.Children()
+				o.Child("sub0")
+				o.Methods()
+				o.Method("m0")
+				o.Static("m0")
+				o.Fields()
+				o.Field("Name")
+				o.FieldDoc("Name")
+				o.Doc()
+				o.New()
+				o.Actions()
+				_ = o.Action
+				if m := o.Method("m1"); m != nil {
+					m.Doc()
+					m.RequiresInstance()
+				}
+				r.All()
+				pobj.GetByTypeIn[TestPerson](r)
+			}
+		}()
+	}
+
+	for i := 0; i < 50; i++ {
+		r.RegisterMethod(fmt.Sprintf("concurrent:m%d", i), func(ctx context.Context) error { return nil }).
+			SetDoc("doc").
+			SetRequiresInstance(true)
+		pobj.RegisterIn[struct{}](r, fmt.Sprintf("concurrent/sub%d", i))
+		obj.SetDoc(fmt.Sprintf("doc %d", i))
+		obj.SetFieldDoc("Name", fmt.Sprintf("name %d", i))
+		pobj.ReplaceIn[TestPerson](r, "concurrent", &pobj.ObjectActions{})
+	}
+	close(stop)
+	wg.Wait()
+
+	if len(obj.Methods()) != 50 {
+		t.Errorf("Wrong number of methods, got %d, want 50", len(obj.Methods()))
+	}
+	if len(obj.Children()) != 50 {
+		t.Errorf("Wrong number of children, got %d, want 50", len(obj.Children()))
+	}
+}
@@ -0,0 +1,465 @@
+package pobj
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SchemaDialect is the JSON Schema dialect of the generated schemas.
+const SchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// Schema is a JSON Schema, as generated by Object.JSONSchema and
+// Registry.JSONSchema. Only the keywords needed to describe Go types are
+// included.
+type Schema struct {
+	Schema               string             `json:"$schema,omitempty"`
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	ContentEncoding      string             `json:"contentEncoding,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	ReadOnly             bool               `json:"readOnly,omitempty"`
+	Enum                 []any              `json:"enum,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	PrefixItems          []*Schema          `json:"prefixItems,omitempty"`
+	Defs                 map[string]*Schema `json:"$defs,omitempty"`
+}
+
+var (
+	timeTyp          = reflect.TypeOf(time.Time{})
+	rawMessageTyp    = reflect.TypeOf(json.RawMessage{})
+	jsonMarshalerTyp = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerTyp = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// schemaGen generates schemas for Go types, placing named struct types in
+// defs and referencing them with $ref.
+type schemaGen struct {
+	reg       *Registry
+	refPrefix string                  // prefix of $ref values, such as "#/$defs/"
+	bundle    bool                    // if true, registered types are named after their path
+	root      reflect.Type            // type of the root document, referenced as "#"
+	defs      map[string]*Schema      // generated definitions
+	names     map[reflect.Type]string // definition name of each named struct type
+
+	// In component mode, definition names are valid OpenAPI component keys
+	component bool
+	keys      map[string]string // key of each path or type name
+	used      map[string]bool   // keys already assigned or reserved
+}
+
+func newSchemaGen(r *Registry, refPrefix string) *schemaGen {
+	return &schemaGen{
+		reg:       r,
+		refPrefix: refPrefix,
+		defs:      make(map[string]*Schema),
+		names:     make(map[reflect.Type]string),
+	}
+}
+
+// JSONSchema returns a self-contained JSON Schema describing the type of
+// this object, with the object documentation as description and field
+// documentation as property descriptions. Named struct types used by the
+// fields are placed in $defs. Returns nil if the object has no type.
+//
+// Struct fields are described the way encoding/json encodes them: json tags
+// rename or skip fields, embedded structs are flattened, and fields are
+// required unless they are pointers or tagged omitempty.
+func (o *Object) JSONSchema() *Schema {
+	typ := o.rtype()
+	if typ == nil {
+		return nil
+	}
+	g := newSchemaGen(o.reg, "#/$defs/")
+	g.root = typ
+	s := g.typeSchema(typ, o)
+	s.Schema = SchemaDialect
+	if len(g.defs) > 0 {
+		s.Defs = g.defs
+	}
+	return s
+}
+
+// JSONSchema returns a JSON Schema bundling the schemas of all the objects
+// of the DefaultRegistry. See Registry.JSONSchema.
+func JSONSchema() *Schema {
+	return DefaultRegistry.JSONSchema()
+}
+
+// JSONSchema returns a JSON Schema holding, in $defs, the schema of every
+// registered object keyed by its path (such as "user/admin"). Objects
+// reference each other with $ref, as do other named struct types which are
+// also placed in $defs.
+func (r *Registry) JSONSchema() *Schema {
+	b := r.NewSchemaBuilder("#/$defs/")
+	return &Schema{Schema: SchemaDialect, Defs: b.Defs()}
+}
+
+// SchemaBuilder generates JSON Schemas for Go types using a shared set of
+// definitions, which holds the schema of every registered object keyed by
+// its path, and of the other named struct types. It is used to embed
+// schemas in other documents, such as OpenAPI specifications.
+type SchemaBuilder struct {
+	g *schemaGen
+}
+
+// NewSchemaBuilder returns a SchemaBuilder with the definitions of all the
+// objects of r. References to definitions are refPrefix followed by the
+// definition name, such as "#/components/schemas/user".
+func (r *Registry) NewSchemaBuilder(refPrefix string) *SchemaBuilder {
+	return r.newSchemaBuilder(newSchemaGen(r, refPrefix))
+}
+
+// NewComponentsBuilder returns a SchemaBuilder for the components of an
+// OpenAPI document, referenced as "#/components/schemas/" followed by the
+// definition name. Definition names only hold the characters allowed in
+// component keys, so that an object path such as "admin/user" becomes
+// "admin.user", and the names in reserved are left to the caller for its
+// own components. A suffix such as "_2" is added to names that would
+// collide.
+func (r *Registry) NewComponentsBuilder(reserved ...string) *SchemaBuilder {
+	g := newSchemaGen(r, "#/components/schemas/")
+	g.component = true
+	g.keys = make(map[string]string)
+	g.used = make(map[string]bool)
+	for _, name := range reserved {
+		g.used[name] = true
+	}
+	return r.newSchemaBuilder(g)
+}
+
+// newSchemaBuilder adds the objects of r to the definitions of g.
+func (r *Registry) newSchemaBuilder(g *schemaGen) *SchemaBuilder {
+	g.bundle = true
+	for _, o := range r.All() {
+		typ := o.rtype()
+		name := g.key(o.String())
+		if _, ok := g.defs[name]; ok {
+			continue
+		}
+		if typ.Kind() == reflect.Struct && typ.Name() != "" && g.reg.GetByType(typ) == o {
+			// canonical registration, referenced by other objects
+			g.ref(typ)
+			continue
+		}
+		g.defs[name] = g.typeSchema(typ, o)
+	}
+	return &SchemaBuilder{g: g}
+}
+
+// Schema returns the schema of typ. Named struct types are referenced, and
+// their definition is added if needed.
+func (b *SchemaBuilder) Schema(typ reflect.Type) *Schema {
+	return b.g.schema(typ)
+}
+
+// Ref returns a schema referencing the definition of object o, which must
+// have a type.
+func (b *SchemaBuilder) Ref(o *Object) *Schema {
+	return &Schema{Ref: b.g.refPrefix + escapePointer(b.g.key(o.String()))}
+}
+
+// Defs returns the definitions, keyed by name.
+func (b *SchemaBuilder) Defs() map[string]*Schema {
+	return b.g.defs
+}
+
+// defName returns the name of the definition of the named struct type typ.
+// In bundle mode, registered types are named after their object path.
+func (g *schemaGen) defName(typ reflect.Type) string {
+	if name, ok := g.names[typ]; ok {
+		return name
+	}
+	var name string
+	if g.bundle {
+		if o := g.reg.GetByType(typ); o != nil {
+			name = g.key(o.String())
+		}
+	}
+	if name == "" {
+		name = g.key(typ.Name())
+		if _, taken := g.defs[name]; taken {
+			// same name in different packages
+			name = g.key(strings.ReplaceAll(typ.PkgPath(), "/", ".") + "." + typ.Name())
+		}
+	}
+	g.names[typ] = name
+	return name
+}
+
+// key returns the definition name of name, an object path or a type name.
+// In component mode, characters other than letters, digits, '.', '-' and
+// '_' are replaced with '.', and a suffix is added to names already used.
+func (g *schemaGen) key(name string) string {
+	if !g.component {
+		return name
+	}
+	if k, ok := g.keys[name]; ok {
+		return k
+	}
+	base := strings.Map(func(c rune) rune {
+		if c < 0x80 && (c == '.' || c == '-' || c == '_' || '0' <= c && c <= '9' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z') {
+			return c
+		}
+		return '.'
+	}, name)
+	k := base
+	for i := 2; g.used[k]; i++ {
+		k = base + "_" + strconv.Itoa(i)
+	}
+	g.keys[name] = k
+	g.used[k] = true
+	return k
+}
+
+// ref returns a schema referencing the definition of the named struct type
+// typ, generating the definition if needed.
+func (g *schemaGen) ref(typ reflect.Type) *Schema {
+	if typ == g.root {
+		return &Schema{Ref: "#"}
+	}
+	_, known := g.names[typ]
+	name := g.defName(typ)
+	if !known {
+		g.defs[name] = nil // reserve the name while generating recursive types
+		g.defs[name] = g.typeSchema(typ, g.reg.GetByType(typ))
+	}
+	return &Schema{Ref: g.refPrefix + escapePointer(name)}
+}
+
+// schema returns the schema of typ, referencing named struct types.
+func (g *schemaGen) schema(typ reflect.Type) *Schema {
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	if typ.Kind() == reflect.Struct && typ.Name() != "" && typ != timeTyp && !marshals(typ) {
+		return g.ref(typ)
+	}
+	return g.typeSchema(typ, nil)
+}
+
+// typeSchema returns the schema of typ without referencing it. If o is not
+// nil, its documentation is included.
+func (g *schemaGen) typeSchema(typ reflect.Type, o *Object) *Schema {
+	s := g.kindSchema(typ, o)
+	if o != nil {
+		s.Description = o.Doc()
+	}
+	return s
+}
+
+// kindSchema returns the schema of typ based on its kind. The field
+// documentation of o, if not nil, is included.
+func (g *schemaGen) kindSchema(typ reflect.Type, o *Object) *Schema {
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	switch {
+	case typ == timeTyp:
+		return &Schema{Type: "string", Format: "date-time"}
+	case typ == rawMessageTyp:
+		return &Schema{}
+	case reflect.PointerTo(typ).Implements(jsonMarshalerTyp):
+		// custom encoding, cannot be described
+		return &Schema{}
+	case reflect.PointerTo(typ).Implements(textMarshalerTyp):
+		return &Schema{Type: "string"}
+	}
+
+	switch typ.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if typ.Kind() == reflect.Slice && typ.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", ContentEncoding: "base64"}
+		}
+		return &Schema{Type: "array", Items: g.schema(typ.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.schema(typ.Elem())}
+	case reflect.Struct:
+		s := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+		g.structFields(s, typ, o)
+		return s
+	}
+	// interfaces, funcs, channels: any value
+	return &Schema{}
+}
+
+// structFields adds the fields of struct type typ to s, with the field
+// documentation of o and the options of their pobj tag. Hidden fields are
+// skipped.
+func (g *schemaGen) structFields(s *Schema, typ reflect.Type, o *Object) {
+	for _, f := range jsonFields(typ) {
+		meta := o.Field(f.Name)
+		if meta == nil {
+			// types used by registered types have no field metadata
+			meta, _ = newField(f.StructField)
+		}
+		if meta.Hidden() {
+			continue
+		}
+		var fs *Schema
+		if hasOpt(f.opts, "string") {
+			fs = &Schema{Type: "string"}
+		} else {
+			fs = g.schema(f.Type)
+			fs.setOptions(meta)
+		}
+		// fs is never shared, and sibling keywords of $ref are allowed
+		// since draft 2019-09
+		fs.Description = meta.Doc()
+		s.Properties[f.jsonName] = fs
+		if f.required() || meta.Required() {
+			s.Required = append(s.Required, f.jsonName)
+		}
+	}
+}
+
+// setOptions sets the keywords of s matching the pobj tag options of f.
+func (s *Schema) setOptions(f *Field) {
+	s.ReadOnly = f.ReadOnly()
+	s.Pattern = f.Pattern()
+	for _, e := range f.Enum() {
+		if s.Type == "string" {
+			s.Enum = append(s.Enum, e)
+		} else {
+			s.Enum = append(s.Enum, json.Number(e))
+		}
+	}
+	min, hasMin := f.Min()
+	max, hasMax := f.Max()
+	var minLen, maxLen **int
+	switch s.Type {
+	case "integer", "number":
+		if hasMin {
+			s.Minimum = &min
+		}
+		if hasMax {
+			s.Maximum = &max
+		}
+		return
+	case "string":
+		minLen, maxLen = &s.MinLength, &s.MaxLength
+	case "array":
+		minLen, maxLen = &s.MinItems, &s.MaxItems
+	default:
+		// maps have no length keywords
+		return
+	}
+	if hasMin {
+		n := int(min)
+		*minLen = &n
+	}
+	if hasMax {
+		n := int(max)
+		*maxLen = &n
+	}
+}
+
+// jsonField is a struct field as encoded by encoding/json.
+type jsonField struct {
+	reflect.StructField
+	jsonName string // name of the field in JSON
+	opts     string // options of the json tag, such as "omitempty"
+}
+
+// required returns true if the field is always present in JSON: it is not
+// a pointer and not tagged omitempty.
+func (f *jsonField) required() bool {
+	return !hasOpt(f.opts, "omitempty") && !hasOpt(f.opts, "omitzero") && f.Type.Kind() != reflect.Pointer
+}
+
+// jsonFields returns the fields of struct type typ the way encoding/json
+// encodes them: json tags rename or skip fields, and the fields of embedded
+// structs are flattened after the fields of typ. Fields already defined at a
+// shallower depth are skipped.
+func jsonFields(typ reflect.Type) []jsonField {
+	var res []jsonField
+	seen := make(map[string]bool)
+	visited := make(map[reflect.Type]bool)
+	var walk func(typ reflect.Type)
+	walk = func(typ reflect.Type) {
+		if visited[typ] {
+			return
+		}
+		visited[typ] = true
+		var embedded []reflect.Type
+		for i := 0; i < typ.NumField(); i++ {
+			sf := typ.Field(i)
+			name, opts, _ := strings.Cut(sf.Tag.Get("json"), ",")
+			if name == "-" && opts == "" {
+				continue
+			}
+			if sf.Anonymous && name == "" {
+				et := sf.Type
+				if et.Kind() == reflect.Pointer {
+					et = et.Elem()
+				}
+				if et.Kind() == reflect.Struct {
+					embedded = append(embedded, et)
+					continue
+				}
+			}
+			if !sf.IsExported() {
+				continue
+			}
+			if name == "" {
+				name = sf.Name
+			}
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+			res = append(res, jsonField{StructField: sf, jsonName: name, opts: opts})
+		}
+		for _, et := range embedded {
+			walk(et)
+		}
+	}
+	walk(typ)
+	return res
+}
+
+// hasOpt returns true if the comma separated json tag options contain opt.
+func hasOpt(opts, opt string) bool {
+	for opts != "" {
+		var o string
+		o, opts, _ = strings.Cut(opts, ",")
+		if o == opt {
+			return true
+		}
+	}
+	return false
+}
+
+// marshals returns true if typ has its own JSON or text encoding.
+func marshals(typ reflect.Type) bool {
+	p := reflect.PointerTo(typ)
+	return p.Implements(jsonMarshalerTyp) || p.Implements(textMarshalerTyp)
+}
+
+// escapePointer escapes name for use in a JSON Pointer (RFC 6901).
+func escapePointer(name string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(name, "~", "~0"), "/", "~1")
+}
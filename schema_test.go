@@ -0,0 +1,109 @@
+package pobj_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/KarpelesLab/pobj"
+)
+
+type schemaBase struct {
+	ID      string    `json:"id"`
+	Created time.Time `json:"created"`
+}
+
+type schemaAddress struct {
+	City string
+}
+
+type schemaUser struct {
+	schemaBase
+	Name     string             `json:"name"`
+	Email    string             `json:"email,omitempty"`
+	Age      int                `json:"age,string"`
+	Tags     []string           `json:"tags"`
+	Meta     map[string]any     `json:"meta,omitempty"`
+	Address  *schemaAddress     `json:"address"`
+	Friends  []*schemaUser      `json:"friends,omitempty"`
+	Company  *schemaCompany     `json:"company,omitempty"`
+	Avatar   []byte             `json:"avatar,omitempty"`
+	Secret   string             `json:"-"`
+	Scores   map[string]float64 `json:"scores"`
+	internal int
+}
+
+type schemaCompany struct {
+	Name string `json:"name"`
+}
+
+func TestJSONSchema(t *testing.T) {
+	r := pobj.NewRegistry()
+	pobj.RegisterIn[schemaUser](r, "user").SetDoc("A user").SetFieldDoc("Name", "Full name").SetFieldDoc("Address", "Postal address")
+	pobj.RegisterIn[schemaCompany](r, "org/company").SetDoc("A company")
+
+	s := r.Get("user").JSONSchema()
+	if s.Schema != pobj.SchemaDialect || s.Type != "object" || s.Description != "A user" {
+		t.Errorf("wrong schema header, got %+v", s)
+	}
+	want := map[string]*pobj.Schema{
+		"id":      {Type: "string"},
+		"created": {Type: "string", Format: "date-time"},
+		"name":    {Type: "string", Description: "Full name"},
+		"email":   {Type: "string"},
+		"age":     {Type: "string"},
+		"tags":    {Type: "array", Items: &pobj.Schema{Type: "string"}},
+		"meta":    {Type: "object", AdditionalProperties: &pobj.Schema{}},
+		"address": {Ref: "#/$defs/schemaAddress", Description: "Postal address"},
+		"friends": {Type: "array", Items: &pobj.Schema{Ref: "#"}},
+		"company": {Ref: "#/$defs/schemaCompany"},
+		"avatar":  {Type: "string", ContentEncoding: "base64"},
+		"scores":  {Type: "object", AdditionalProperties: &pobj.Schema{Type: "number"}},
+	}
+	if !reflect.DeepEqual(s.Properties, want) {
+		got, _ := json.Marshal(s.Properties)
+		t.Errorf("wrong properties, got %s", got)
+	}
+	wantReq := []string{"name", "age", "tags", "scores", "id", "created"}
+	if !reflect.DeepEqual(s.Required, wantReq) {
+		t.Errorf("wrong required, got %v, want %v", s.Required, wantReq)
+	}
+	if d := s.Defs["schemaCompany"]; d == nil || d.Description != "A company" || d.Properties["name"] == nil {
+		t.Errorf("wrong company definition, got %+v", d)
+	}
+	if d := s.Defs["schemaAddress"]; d == nil || d.Properties["City"] == nil {
+		t.Errorf("wrong address definition, got %+v", d)
+	}
+
+	if r.Get("org").JSONSchema() != nil {
+		t.Error("JSONSchema of an object without type should be nil")
+	}
+}
+
+func TestRegistryJSONSchema(t *testing.T) {
+	r := pobj.NewRegistry()
+	pobj.RegisterIn[schemaUser](r, "user")
+	pobj.RegisterIn[schemaCompany](r, "org/company")
+
+	s := r.JSONSchema()
+	if len(s.Defs) != 3 {
+		t.Errorf("wrong number of definitions, got %d", len(s.Defs))
+	}
+	u := s.Defs["user"]
+	if u == nil {
+		t.Fatal("missing user definition")
+	}
+	if got := u.Properties["company"].Ref; got != "#/$defs/org~1company" {
+		t.Errorf("wrong company reference, got %s", got)
+	}
+	if got := u.Properties["friends"].Items.Ref; got != "#/$defs/user" {
+		t.Errorf("wrong friends reference, got %s", got)
+	}
+	if s.Defs["org/company"] == nil || s.Defs["schemaAddress"] == nil {
+		t.Errorf("missing definitions, got %v", s.Defs)
+	}
+	if _, err := json.Marshal(s); err != nil {
+		t.Errorf("cannot encode schema: %v", err)
+	}
+}
@@ -0,0 +1,118 @@
+package pobj
+
+import "fmt"
+
+// TypeID returns the numeric ID identifying the type of this object in
+// binary encodings (see NewEncoder), or 0 if the object has no type.
+//
+// IDs are assigned per path, starting from 1, the first time they are
+// needed, and are kept when the object is unregistered or replaced. An ID
+// is therefore only stable across runs of a program if the IDs of the
+// previous runs are loaded with LoadTypeIDs before any is assigned. IDs are
+// recorded in manifests for this purpose, see Registry.Manifest.
+func (o *Object) TypeID() uint64 {
+	if o == nil || o.rtype() == nil {
+		return 0
+	}
+	path := o.String()
+	if id, ok := o.reg.typeIDs.get(path); ok {
+		return id
+	}
+	o.reg.mu.Lock()
+	defer o.reg.mu.Unlock()
+	return o.reg.assignTypeID(path)
+}
+
+// assignTypeID returns the type ID of path, assigning the next free one if
+// needed. Caller must hold r.mu.
+func (r *Registry) assignTypeID(path string) uint64 {
+	if id, ok := r.typeIDs.get(path); ok {
+		return id
+	}
+	r.lastTypeID++
+	r.typeIDs.set(path, r.lastTypeID)
+	r.typePaths.set(r.lastTypeID, path)
+	return r.lastTypeID
+}
+
+// ByTypeID returns the object of the DefaultRegistry with the given type ID.
+// See Registry.ByTypeID.
+func ByTypeID(id uint64) *Object {
+	return DefaultRegistry.ByTypeID(id)
+}
+
+// ByTypeID returns the object whose type has the given ID (see
+// Object.TypeID), or nil if no type is registered with that ID.
+func (r *Registry) ByTypeID(id uint64) *Object {
+	path, ok := r.typePaths.get(id)
+	if !ok {
+		return nil
+	}
+	if o := r.Get(path); o.HasType() {
+		return o
+	}
+	return nil
+}
+
+// LoadTypeIDs assigns the type IDs recorded in manifest m to the objects of
+// the DefaultRegistry. See Registry.LoadTypeIDs.
+func LoadTypeIDs(m *Manifest) error {
+	return DefaultRegistry.LoadTypeIDs(m)
+}
+
+// LoadTypeIDs assigns the type IDs recorded in manifest m, as produced by a
+// previous version of the program, to the paths of its objects, so that
+// values encoded by that version can be decoded. Paths do not need to be
+// registered yet, and IDs assigned later start after the highest known ID.
+//
+// It is typically called at startup with an embedded manifest, before any
+// encoding:
+//
+//	//go:embed pobj.json
+//	var manifest []byte
+//
+//	m, err := pobj.LoadManifest(bytes.NewReader(manifest))
+//	...
+//	err = pobj.LoadTypeIDs(m)
+//
+// Returns an error wrapping ErrTypeIDConflict, and loads nothing, if a path
+// already has another ID or an ID is used by another path.
+func (r *Registry) LoadTypeIDs(m *Manifest) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ids := make(map[uint64]string)
+	for _, om := range m.Objects {
+		if om.TypeID == 0 {
+			continue
+		}
+		if path, ok := ids[om.TypeID]; ok && path != om.Path {
+			return fmt.Errorf("%w: ID %d used by %s and %s", ErrTypeIDConflict, om.TypeID, path, om.Path)
+		}
+		if id, ok := r.typeIDs.get(om.Path); ok && id != om.TypeID {
+			return fmt.Errorf("%w: %s has ID %d, not %d", ErrTypeIDConflict, om.Path, id, om.TypeID)
+		}
+		if path, ok := r.typePaths.get(om.TypeID); ok && path != om.Path {
+			return fmt.Errorf("%w: ID %d is used by %s, not %s", ErrTypeIDConflict, om.TypeID, path, om.Path)
+		}
+		ids[om.TypeID] = om.Path
+	}
+	for id, path := range ids {
+		r.typeIDs.set(path, id)
+		r.typePaths.set(id, path)
+		r.lastTypeID = max(r.lastTypeID, id)
+	}
+	return nil
+}
+
+// assignTypeIDs assigns type IDs to the objects of m that have a type, in
+// the order of m.Objects, and records them in m.
+func (r *Registry) assignTypeIDs(m *Manifest) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, om := range m.Objects {
+		if om.Type != "" {
+			om.TypeID = r.assignTypeID(om.Path)
+		}
+	}
+}
@@ -0,0 +1,413 @@
+package pobj
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/KarpelesLab/typutil"
+)
+
+// Required returns true if the field is tagged required: Validate rejects
+// zero values, such as empty strings and nil pointers. A pointer to a zero
+// value, such as a *int pointing to 0, is present and accepted.
+//
+// Field options are set at registration from the pobj tag of the struct
+// field, a comma separated list of options:
+//
+//	type User struct {
+//		ID    string `pobj:"readonly"`
+//		Name  string `pobj:"required,min=1,max=64"`
+//		Age   int    `pobj:"min=18"`
+//		Role  string `pobj:"enum=admin|user"`
+//		Email string `pobj:"required,pattern=^[^@]+@[^@]+$"`
+//		Token string `pobj:"hidden"`
+//	}
+//
+// min and max bound numbers, and the length of strings (in characters),
+// slices and maps. pattern is a regular expression strings must match; it
+// takes the rest of the tag, so it may contain commas but must come last.
+// enum lists the allowed values, separated by "|". These options are only
+// checked on non-zero values and non-nil pointers, add required to reject
+// zero values too.
+func (f *Field) Required() bool {
+	return f != nil && f.required
+}
+
+// ReadOnly returns true if the field is tagged readonly: it is set by the
+// server, and marked readOnly in schemas. Validate does not check it.
+func (f *Field) ReadOnly() bool {
+	return f != nil && f.readonly
+}
+
+// Hidden returns true if the field is tagged hidden: it is left out of
+// schemas and manifests.
+func (f *Field) Hidden() bool {
+	return f != nil && f.hidden
+}
+
+// Min returns the lower bound of the value, or of the length, of the field
+// and true if it is tagged with min.
+func (f *Field) Min() (float64, bool) {
+	if f == nil || f.min == nil {
+		return 0, false
+	}
+	return *f.min, true
+}
+
+// Max returns the upper bound of the value, or of the length, of the field
+// and true if it is tagged with max.
+func (f *Field) Max() (float64, bool) {
+	if f == nil || f.max == nil {
+		return 0, false
+	}
+	return *f.max, true
+}
+
+// Pattern returns the regular expression the field must match, or an empty
+// string.
+func (f *Field) Pattern() string {
+	if f == nil || f.pattern == nil {
+		return ""
+	}
+	return f.pattern.String()
+}
+
+// Enum returns the allowed values of the field, or nil.
+func (f *Field) Enum() []string {
+	if f == nil {
+		return nil
+	}
+	return f.enum
+}
+
+// hasRules returns true if Validate checks the field.
+func (f *Field) hasRules() bool {
+	return f.index != nil && (f.required || f.min != nil || f.max != nil || f.pattern != nil || f.enum != nil)
+}
+
+// typeFields returns the metadata of the exported fields of struct type typ,
+// including the fields promoted from embedded structs, with the options of
+// their pobj tag. Errors wrap ErrInvalidTag.
+func typeFields(typ reflect.Type) (map[string]*Field, error) {
+	if typ.Kind() != reflect.Struct {
+		return nil, nil
+	}
+	res := make(map[string]*Field)
+	for _, sf := range reflect.VisibleFields(typ) {
+		if !sf.IsExported() || sf.Anonymous && indirect(sf.Type).Kind() == reflect.Struct {
+			continue
+		}
+		if found, _ := typ.FieldByName(sf.Name); !slices.Equal(found.Index, sf.Index) {
+			// ambiguous field, not accessible by name
+			continue
+		}
+		f, err := newField(sf)
+		if err != nil {
+			return nil, err
+		}
+		res[sf.Name] = f
+	}
+	return res, nil
+}
+
+// newField returns the metadata of struct field sf, with the options of its
+// pobj tag. Errors wrap ErrInvalidTag.
+func newField(sf reflect.StructField) (*Field, error) {
+	f := &Field{name: sf.Name, typ: sf.Type, index: sf.Index}
+	tag := sf.Tag.Get("pobj")
+	for tag != "" {
+		var opt string
+		if strings.HasPrefix(tag, "pattern=") {
+			opt, tag = tag, ""
+		} else {
+			opt, tag, _ = strings.Cut(tag, ",")
+		}
+		if err := f.setOption(opt); err != nil {
+			return nil, fmt.Errorf("%w: field %s: option %q: %v", ErrInvalidTag, sf.Name, opt, err)
+		}
+	}
+	return f, nil
+}
+
+// setOption sets the pobj tag option opt, of the form "name" or
+// "name=value".
+func (f *Field) setOption(opt string) error {
+	name, val, hasVal := strings.Cut(opt, "=")
+	typ := indirect(f.typ)
+	switch name {
+	case "required", "readonly", "hidden":
+		if hasVal {
+			return errors.New("takes no value")
+		}
+		switch name {
+		case "required":
+			f.required = true
+		case "readonly":
+			f.readonly = true
+		default:
+			f.hidden = true
+		}
+	case "min", "max":
+		if !isNumber(typ) && !hasLen(typ) {
+			return fmt.Errorf("not supported by %s", f.typ)
+		}
+		n, err := strconv.ParseFloat(val, 64)
+		if err != nil {
+			return err
+		}
+		if name == "min" {
+			f.min = &n
+		} else {
+			f.max = &n
+		}
+	case "pattern":
+		if typ.Kind() != reflect.String {
+			return fmt.Errorf("not supported by %s", f.typ)
+		}
+		re, err := regexp.Compile(val)
+		if err != nil {
+			return err
+		}
+		f.pattern = re
+	case "enum":
+		if typ.Kind() != reflect.String && !isNumber(typ) {
+			return fmt.Errorf("not supported by %s", f.typ)
+		}
+		if val == "" {
+			return errors.New("no values")
+		}
+		f.enum = strings.Split(val, "|")
+		if isNumber(typ) {
+			for _, e := range f.enum {
+				if _, err := strconv.ParseFloat(e, 64); err != nil {
+					return err
+				}
+			}
+		}
+	default:
+		return errors.New("unknown option")
+	}
+	return nil
+}
+
+// setFields publishes fields as the field metadata of o, keeping the
+// documentation and List options of the existing fields. Existing fields
+// missing from fields are kept, without struct tag options. Caller must hold
+// the registry lock.
+func (o *Object) setFields(fields map[string]*Field) {
+	cur := o.fields.load()
+	if len(cur) == 0 && len(fields) == 0 {
+		return
+	}
+	m := make(map[string]*Field, len(cur)+len(fields))
+	for name, f := range fields {
+		f.object = o
+		m[name] = f
+	}
+	for name, old := range cur {
+		f, ok := m[name]
+		if !ok {
+			f = &Field{name: name, object: o}
+			m[name] = f
+		}
+		if doc := old.doc.Load(); doc != nil {
+			f.doc.Store(doc)
+		}
+		f.filterable.Store(old.filterable.Load())
+		f.sortable.Store(old.sortable.Load())
+	}
+	o.fields.store(m)
+}
+
+// Validate checks the fields of instance, a value or a pointer to a value of
+// the object's type, against the options of their pobj tag (see
+// Field.Required). It returns nil if instance is valid or nil, or a
+// *ValidationError listing all the invalid fields.
+//
+// Create and Update validate their data before calling the action, after
+// the interceptors ran, so they can fill in fields. Data of another type,
+// such as the JSON passed by pobjhttp, is converted to the object's type to
+// be validated, and still passed as is to the action. Patch is not
+// validated.
+func (o *Object) Validate(instance any) error {
+	if o == nil {
+		return nil
+	}
+	v := reflect.ValueOf(instance)
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return nil
+	}
+	if typ := o.rtype(); v.Type() != typ {
+		return fmt.Errorf("pobj: cannot validate %s as %s", v.Type(), o)
+	}
+
+	var fields []*Field
+	for _, f := range o.fields.load() {
+		if f.hasRules() {
+			fields = append(fields, f)
+		}
+	}
+	slices.SortFunc(fields, func(a, b *Field) int { return slices.Compare(a.index, b.index) })
+
+	var res []*FieldError
+	for _, f := range fields {
+		// fields of nil embedded pointers are zero
+		fv, _ := v.FieldByIndexErr(f.index)
+		if msg := f.check(fv); msg != "" {
+			res = append(res, &FieldError{Field: f.name, Message: msg})
+		}
+	}
+	if len(res) == 0 {
+		return nil
+	}
+	return &ValidationError{Path: o.String(), Fields: res}
+}
+
+// check returns why v, the value of the field, does not satisfy its
+// options, or an empty string. The invalid Value stands for a zero value.
+func (f *Field) check(v reflect.Value) string {
+	// a non-nil pointer is present, even if it points to a zero value
+	present := v.IsValid() && !v.IsZero()
+	for v.Kind() == reflect.Pointer && !v.IsNil() {
+		v = v.Elem()
+	}
+	if !present {
+		if f.required {
+			return "is required"
+		}
+		return ""
+	}
+	if f.min != nil || f.max != nil {
+		n, what := measure(v)
+		if f.min != nil && n < *f.min {
+			return "must " + what + "at least " + formatFloat(*f.min)
+		}
+		if f.max != nil && n > *f.max {
+			return "must " + what + "at most " + formatFloat(*f.max)
+		}
+	}
+	if f.pattern != nil && !f.pattern.MatchString(v.String()) {
+		return "must match " + strconv.Quote(f.pattern.String())
+	}
+	if f.enum != nil && !f.inEnum(v) {
+		return "must be one of " + strings.Join(f.enum, ", ")
+	}
+	return ""
+}
+
+// inEnum returns true if v is one of the allowed values of the field.
+func (f *Field) inEnum(v reflect.Value) bool {
+	if v.Kind() == reflect.String {
+		return slices.Contains(f.enum, v.String())
+	}
+	n, _ := measure(v)
+	for _, e := range f.enum {
+		if en, _ := strconv.ParseFloat(e, 64); en == n {
+			return true
+		}
+	}
+	return false
+}
+
+// validateData validates the data passed to Create or Update. Data of
+// another type than the object's is converted first, and left to the action
+// to reject if it cannot be.
+func (o *Object) validateData(data any) error {
+	typ := o.rtype()
+	if typ == nil || data == nil || !o.hasRules() {
+		return nil
+	}
+	if t := reflect.TypeOf(data); indirect(t) != typ {
+		ptr := reflect.New(typ)
+		if typutil.Assign(ptr.Interface(), data) != nil {
+			return nil
+		}
+		data = ptr.Interface()
+	}
+	return o.Validate(data)
+}
+
+// validated returns an Invoker validating the data of the invocation with
+// Object.Validate before calling call.
+func validated(call Invoker) Invoker {
+	return func(ctx context.Context, inv *Invocation) (any, error) {
+		if len(inv.Args) > 0 {
+			if err := inv.Object.validateData(inv.Args[0]); err != nil {
+				return nil, err
+			}
+		}
+		return call(ctx, inv)
+	}
+}
+
+// hasRules returns true if Validate checks any field of o.
+func (o *Object) hasRules() bool {
+	for _, f := range o.fields.load() {
+		if f.hasRules() {
+			return true
+		}
+	}
+	return false
+}
+
+// measure returns the value of number v, or the length of v and "have a
+// length of " to describe it.
+func measure(v reflect.Value) (float64, string) {
+	switch {
+	case v.CanInt():
+		return float64(v.Int()), "be "
+	case v.CanUint():
+		return float64(v.Uint()), "be "
+	case v.CanFloat():
+		return v.Float(), "be "
+	case v.Kind() == reflect.String:
+		return float64(utf8.RuneCountInString(v.String())), "have a length of "
+	}
+	return float64(v.Len()), "have a length of "
+}
+
+// isNumber returns true if typ is an integer or floating-point type.
+func isNumber(typ reflect.Type) bool {
+	switch typ.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// hasLen returns true if values of typ have a length.
+func hasLen(typ reflect.Type) bool {
+	switch typ.Kind() {
+	case reflect.String, reflect.Slice, reflect.Array, reflect.Map:
+		return true
+	}
+	return false
+}
+
+// indirect returns the type typ points to, through any number of pointers.
+func indirect(typ reflect.Type) reflect.Type {
+	for typ != nil && typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	return typ
+}
+
+// formatFloat formats n the shortest way, such as "18" or "0.5".
+func formatFloat(n float64) string {
+	return strconv.FormatFloat(n, 'g', -1, 64)
+}
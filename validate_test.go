@@ -0,0 +1,288 @@
+package pobj_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/KarpelesLab/pobj"
+	"github.com/KarpelesLab/typutil"
+)
+
+type tagBase struct {
+	Created string `pobj:"readonly"`
+}
+
+type tagUser struct {
+	tagBase
+	ID     string   `json:"id" pobj:"readonly"`
+	Name   string   `json:"name" pobj:"required,min=2,max=8"`
+	Age    int      `json:"age,omitempty" pobj:"min=18,max=130"`
+	Role   string   `json:"role,omitempty" pobj:"enum=admin|user"`
+	Level  *int     `json:"level,omitempty" pobj:"enum=1|2|3"`
+	Email  string   `json:"email" pobj:"pattern=^[a-z]+@[a-z,.]+$"`
+	Tags   []string `json:"tags,omitempty" pobj:"max=2"`
+	Secret string   `json:"secret,omitempty" pobj:"hidden"`
+	note   string
+}
+
+func TestFieldTags(t *testing.T) {
+	r := pobj.NewRegistry()
+	obj := pobj.RegisterIn[tagUser](r, "user")
+
+	want := []string{"Age", "Created", "Email", "ID", "Level", "Name", "Role", "Secret", "Tags"}
+	if got := obj.Fields(); !reflect.DeepEqual(got, want) {
+		t.Errorf("wrong fields, got %v, want %v", got, want)
+	}
+	if obj.Field("Age").Type() != reflect.TypeOf(0) {
+		t.Errorf("wrong field type, got %v", obj.Field("Age").Type())
+	}
+
+	name := obj.Field("Name")
+	if !name.Required() || name.ReadOnly() || name.Hidden() {
+		t.Error("wrong Name flags")
+	}
+	if min, ok := name.Min(); !ok || min != 2 {
+		t.Errorf("wrong Name min, got %v, %v", min, ok)
+	}
+	if max, ok := name.Max(); !ok || max != 8 {
+		t.Errorf("wrong Name max, got %v, %v", max, ok)
+	}
+	if _, ok := obj.Field("Role").Min(); ok {
+		t.Error("Role should have no min")
+	}
+	if !obj.Field("Created").ReadOnly() || !obj.Field("ID").ReadOnly() || !obj.Field("Secret").Hidden() {
+		t.Error("wrong readonly or hidden flags")
+	}
+	if got := obj.Field("Email").Pattern(); got != "^[a-z]+@[a-z,.]+$" {
+		t.Errorf("wrong pattern, got %q", got)
+	}
+	if got := obj.Field("Role").Enum(); !reflect.DeepEqual(got, []string{"admin", "user"}) {
+		t.Errorf("wrong enum, got %v", got)
+	}
+
+	// documentation and List options are kept when replacing the type
+	obj.SetFieldDoc("Name", "Full name").SetSortable("Name")
+	pobj.ReplaceIn[tagUser](r, "user", nil)
+	if f := obj.Field("Name"); f.Doc() != "Full name" || !f.Sortable() || !f.Required() {
+		t.Error("field metadata lost by Replace")
+	}
+}
+
+func TestFieldTagsInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		reg  func(r *pobj.Registry) error
+	}{
+		{"unknown option", func(r *pobj.Registry) error {
+			_, err := pobj.TryRegisterIn[struct {
+				A string `pobj:"nope"`
+			}](r, "x")
+			return err
+		}},
+		{"bad min", func(r *pobj.Registry) error {
+			_, err := pobj.TryRegisterIn[struct {
+				A int `pobj:"min=x"`
+			}](r, "x")
+			return err
+		}},
+		{"min on bool", func(r *pobj.Registry) error {
+			_, err := pobj.TryRegisterIn[struct {
+				A bool `pobj:"min=1"`
+			}](r, "x")
+			return err
+		}},
+		{"pattern on int", func(r *pobj.Registry) error {
+			_, err := pobj.TryRegisterIn[struct {
+				A int `pobj:"pattern=^1$"`
+			}](r, "x")
+			return err
+		}},
+		{"bad pattern", func(r *pobj.Registry) error {
+			_, err := pobj.TryRegisterIn[struct {
+				A string `pobj:"pattern=("`
+			}](r, "x")
+			return err
+		}},
+		{"bad numeric enum", func(r *pobj.Registry) error {
+			_, err := pobj.TryRegisterIn[struct {
+				A int `pobj:"enum=1|a"`
+			}](r, "x")
+			return err
+		}},
+		{"value on flag", func(r *pobj.Registry) error {
+			_, err := pobj.TryRegisterIn[struct {
+				A int `pobj:"required=yes"`
+			}](r, "x")
+			return err
+		}},
+	}
+	for _, tt := range tests {
+		r := pobj.NewRegistry()
+		if err := tt.reg(r); !errors.Is(err, pobj.ErrInvalidTag) {
+			t.Errorf("%s: wrong error, got %v", tt.name, err)
+		}
+		if r.Get("x") != nil {
+			t.Errorf("%s: type registered despite the error", tt.name)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	r := pobj.NewRegistry()
+	obj := pobj.RegisterIn[tagUser](r, "user")
+	level := 4
+
+	valid := &tagUser{Name: "John", Age: 20, Role: "admin", Email: "john@example.com"}
+	if err := obj.Validate(valid); err != nil {
+		t.Errorf("valid user rejected: %v", err)
+	}
+	if err := obj.Validate(*valid); err != nil {
+		t.Errorf("valid user value rejected: %v", err)
+	}
+	if err := obj.Validate((*tagUser)(nil)); err != nil {
+		t.Errorf("nil user rejected: %v", err)
+	}
+	if err := obj.Validate(&TestPerson{}); err == nil || errors.Is(err, pobj.ErrValidation) {
+		t.Errorf("wrong error for another type, got %v", err)
+	}
+
+	err := obj.Validate(&tagUser{Age: 12, Role: "root", Level: &level, Email: "John", Tags: []string{"a", "b", "c"}})
+	var ve *pobj.ValidationError
+	if !errors.As(err, &ve) || !errors.Is(err, pobj.ErrValidation) {
+		t.Fatalf("wrong error, got %v", err)
+	}
+	want := []*pobj.FieldError{
+		{Field: "Name", Message: "is required"},
+		{Field: "Age", Message: "must be at least 18"},
+		{Field: "Role", Message: "must be one of admin, user"},
+		{Field: "Level", Message: "must be one of 1, 2, 3"},
+		{Field: "Email", Message: `must match "^[a-z]+@[a-z,.]+$"`},
+		{Field: "Tags", Message: "must have a length of at most 2"},
+	}
+	if ve.Path != "user" || !reflect.DeepEqual(ve.Fields, want) {
+		t.Errorf("wrong validation error, got %v", err)
+	}
+
+	// lengths of strings are in characters
+	err = obj.Validate(&tagUser{Name: "É", Age: 200})
+	if got, want := err.Error(), "pobj: validation failed: user: Name must have a length of at least 2, Age must be at most 130"; got != want {
+		t.Errorf("wrong message, got %q, want %q", got, want)
+	}
+
+	// pointers to zero values are present, and still checked
+	type order struct {
+		Qty  *int  `pobj:"required,max=10"`
+		Gift *bool `pobj:"required"`
+	}
+	orders := pobj.RegisterIn[order](r, "order")
+	zero, no, many := 0, false, 11
+	if err := orders.Validate(&order{Qty: &zero, Gift: &no}); err != nil {
+		t.Errorf("pointers to zero values rejected: %v", err)
+	}
+	err = orders.Validate(&order{Qty: &many})
+	if got, want := err.Error(), "pobj: validation failed: order: Qty must be at most 10, Gift is required"; got != want {
+		t.Errorf("wrong message, got %q, want %q", got, want)
+	}
+}
+
+func TestValidateActions(t *testing.T) {
+	r := pobj.NewRegistry()
+	ctx := context.Background()
+	called := 0
+
+	save := func(ctx context.Context, u *tagUser) (*tagUser, error) {
+		called++
+		return u, nil
+	}
+	obj := pobj.RegisterActionsIn[tagUser](r, "user", &pobj.ObjectActions{
+		Create: typutil.Func(save),
+		Update: typutil.Func(func(ctx context.Context, id string, u *tagUser) (*tagUser, error) { return save(ctx, u) }),
+		Patch:  typutil.Func(func(ctx context.Context, id string, patch map[string]any) error { called++; return nil }),
+	})
+
+	tests := []struct {
+		name string
+		call func() error
+		ok   bool
+	}{
+		{"create valid", func() error {
+			_, err := pobj.CreateIn(ctx, r, &tagUser{Name: "John"})
+			return err
+		}, true},
+		{"create invalid", func() error {
+			_, err := pobj.CreateIn(ctx, r, &tagUser{Name: "J"})
+			return err
+		}, false},
+		{"create invalid json", func() error {
+			_, err := obj.Create(ctx, typutil.RawJsonMessage(`{"name":"John","age":3}`))
+			return err
+		}, false},
+		{"create valid json", func() error {
+			_, err := obj.Create(ctx, typutil.RawJsonMessage(`{"name":"John","age":30}`))
+			return err
+		}, true},
+		{"update invalid", func() error {
+			_, err := pobj.UpdateIn(ctx, r, "u1", &tagUser{})
+			return err
+		}, false},
+		{"patch not validated", func() error {
+			_, err := obj.Patch(ctx, "u1", map[string]any{"name": ""})
+			return err
+		}, true},
+	}
+	for _, tt := range tests {
+		called = 0
+		err := tt.call()
+		if tt.ok && (err != nil || called != 1) {
+			t.Errorf("%s: expected success, got %v (called %d)", tt.name, err, called)
+		}
+		if !tt.ok && (!errors.Is(err, pobj.ErrValidation) || called != 0) {
+			t.Errorf("%s: expected ErrValidation before the call, got %v (called %d)", tt.name, err, called)
+		}
+	}
+
+	// interceptors run before validation, and can fill in fields
+	obj.Use(func(ctx context.Context, inv *pobj.Invocation, next pobj.Invoker) (any, error) {
+		if u, ok := inv.Args[0].(*tagUser); ok && u.Name == "" {
+			u.Name = "Jo"
+		}
+		return next(ctx, inv)
+	})
+	if u, err := pobj.CreateIn(ctx, r, &tagUser{}); err != nil || u.Name != "Jo" {
+		t.Errorf("Create failed, got %+v, %v", u, err)
+	}
+}
+
+func TestSchemaTags(t *testing.T) {
+	r := pobj.NewRegistry()
+	s := pobj.RegisterIn[tagUser](r, "user").JSONSchema()
+
+	if _, ok := s.Properties["secret"]; ok {
+		t.Error("hidden field in schema")
+	}
+	if !reflect.DeepEqual(s.Required, []string{"id", "name", "email", "Created"}) {
+		t.Errorf("wrong required fields, got %v", s.Required)
+	}
+	res, _ := json.Marshal(map[string]*pobj.Schema{
+		"id":    s.Properties["id"],
+		"name":  s.Properties["name"],
+		"age":   s.Properties["age"],
+		"role":  s.Properties["role"],
+		"level": s.Properties["level"],
+		"email": s.Properties["email"],
+		"tags":  s.Properties["tags"],
+	})
+	want := `{"age":{"type":"integer","minimum":18,"maximum":130},` +
+		`"email":{"type":"string","pattern":"^[a-z]+@[a-z,.]+$"},` +
+		`"id":{"type":"string","readOnly":true},` +
+		`"level":{"type":"integer","enum":[1,2,3]},` +
+		`"name":{"type":"string","minLength":2,"maxLength":8},` +
+		`"role":{"type":"string","enum":["admin","user"]},` +
+		`"tags":{"type":"array","maxItems":2,"items":{"type":"string"}}}`
+	if string(res) != want {
+		t.Errorf("wrong schemas\ngot  %s\nwant %s", res, want)
+	}
+}
@@ -0,0 +1,179 @@
+package pobj
+
+import (
+	"errors"
+	"iter"
+	"path"
+	"slices"
+	"strings"
+)
+
+// DepthFirst returns an iterator over the descendants of this object in
+// depth-first order: each object is followed by its own descendants before
+// its next sibling. Children are visited in name order, and aliases are
+// skipped so each object is yielded once, at its primary path. Intermediate
+// objects without type are included.
+//
+// The tree is read as the iteration progresses, and stopping the loop ends
+// the walk early:
+//
+//	for o := range pobj.Root().DepthFirst() {
+//		if o.String() == "admin" {
+//			break
+//		}
+//	}
+func (o *Object) DepthFirst() iter.Seq[*Object] {
+	return func(yield func(*Object) bool) {
+		o.depthFirst(yield)
+	}
+}
+
+// depthFirst yields the descendants of o, returning false if yield did.
+func (o *Object) depthFirst(yield func(*Object) bool) bool {
+	for _, c := range o.childObjects() {
+		if !yield(c) || !c.depthFirst(yield) {
+			return false
+		}
+	}
+	return true
+}
+
+// BreadthFirst returns an iterator over the descendants of this object in
+// breadth-first order: all the children of this object, then all their
+// children, and so on. Children are visited in name order, and aliases are
+// skipped as with DepthFirst.
+func (o *Object) BreadthFirst() iter.Seq[*Object] {
+	return func(yield func(*Object) bool) {
+		queue := []*Object{o}
+		for len(queue) > 0 {
+			cur := queue[0]
+			queue = queue[1:]
+			for _, c := range cur.childObjects() {
+				if !yield(c) {
+					return
+				}
+				queue = append(queue, c)
+			}
+		}
+	}
+}
+
+// childObjects returns the children of o sorted by name, without aliases.
+func (o *Object) childObjects() []*Object {
+	names := o.Children()
+	res := make([]*Object, 0, len(names))
+	for _, name := range names {
+		if c := o.Child(name); c != nil && c.parent == o {
+			res = append(res, c)
+		}
+	}
+	return res
+}
+
+// SkipChildren can be returned by the function passed to Object.Walk to
+// skip the descendants of the object it was called for.
+var SkipChildren = errors.New("pobj: skip children")
+
+// SkipAll can be returned by the function passed to Object.Walk to stop the
+// walk without error.
+var SkipAll = errors.New("pobj: skip all")
+
+// Walk calls fn for this object and each of its descendants, in the order
+// of DepthFirst. The root, which is only a holder, is not passed to fn.
+//
+// If fn returns SkipChildren, the descendants of the object are skipped.
+// If it returns SkipAll, the walk stops and Walk returns nil. Any other
+// error stops the walk and is returned by Walk.
+func (o *Object) Walk(fn func(o *Object) error) error {
+	if o == nil {
+		return nil
+	}
+	err := o.walk(fn, o.parent != nil)
+	if err == SkipAll {
+		return nil
+	}
+	return err
+}
+
+// walk calls fn for o if self is true, then for its descendants.
+func (o *Object) walk(fn func(o *Object) error, self bool) error {
+	if self {
+		if err := fn(o); err == SkipChildren {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+	for _, c := range o.childObjects() {
+		if err := c.walk(fn, true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Descendants returns the descendants of this object, in the order of
+// DepthFirst.
+func (o *Object) Descendants() []*Object {
+	return slices.Collect(o.DepthFirst())
+}
+
+// Find returns the objects of the DefaultRegistry matching a glob pattern.
+// See Registry.Find.
+func Find(pattern string) []*Object {
+	return DefaultRegistry.Find(pattern)
+}
+
+// Find returns the objects whose path matches a glob pattern, in the order
+// of DepthFirst. Patterns are matched segment by segment, with the syntax of
+// path.Match for each segment, and a "**" segment matches any number of
+// segments, including none:
+//
+//	r.Find("admin/*")     // children of admin
+//	r.Find("admin/**")    // admin and all its descendants
+//	r.Find("**/settings") // objects named settings, at any depth
+//
+// Leading and trailing slashes are ignored, as with ParsePath. Objects
+// without type are included, use HasType to filter them out. Aliases are not
+// included. Invalid patterns, including empty ones, match nothing.
+func (r *Registry) Find(pattern string) []*Object {
+	pat := strings.Split(strings.Trim(pattern, "/"), "/")
+	for _, p := range pat {
+		if _, err := path.Match(p, ""); err != nil || p == "" {
+			return nil
+		}
+	}
+	var res []*Object
+	for o := range r.root.DepthFirst() {
+		if matchPath(pat, o.Path()) {
+			res = append(res, o)
+		}
+	}
+	return res
+}
+
+// matchPath returns true if the path segments pa match the pattern segments
+// pat.
+func matchPath(pat, pa []string) bool {
+	for len(pat) > 0 {
+		if pat[0] == "**" {
+			for len(pat) > 0 && pat[0] == "**" {
+				pat = pat[1:]
+			}
+			for i := 0; i <= len(pa); i++ {
+				if matchPath(pat, pa[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(pa) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pat[0], pa[0]); !ok {
+			return false
+		}
+		pat, pa = pat[1:], pa[1:]
+	}
+	return len(pa) == 0
+}
@@ -0,0 +1,201 @@
+package pobj_test
+
+import (
+	"iter"
+	"reflect"
+	"testing"
+
+	"github.com/KarpelesLab/pobj"
+)
+
+type walkItem struct {
+	B string
+	A string
+	C string
+}
+
+func walkRegistry() *pobj.Registry {
+	r := pobj.NewRegistry()
+	for _, p := range []string{"zeta", "alpha/two", "alpha/one", "beta/x/y", "beta", "alpha"} {
+		pobj.RegisterIn[walkItem](r, p)
+	}
+	r.Get("zeta").Alias("alpha/link").SetFieldDoc("C", "c").SetFieldDoc("A", "a").SetFieldDoc("B", "b")
+	for _, m := range []string{"zeta:m3", "zeta:m1", "zeta:m2"} {
+		r.RegisterMethod(m, func() {})
+	}
+	return r
+}
+
+func paths(seq iter.Seq[*pobj.Object]) []string {
+	var res []string
+	for o := range seq {
+		res = append(res, o.String())
+	}
+	return res
+}
+
+func TestSortedResults(t *testing.T) {
+	r := walkRegistry()
+
+	if got, want := r.Get("alpha").Children(), []string{"link", "one", "two"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("wrong children, got %v, want %v", got, want)
+	}
+	if got, want := r.Get("zeta").Methods(), []string{"m1", "m2", "m3"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("wrong methods, got %v, want %v", got, want)
+	}
+	if got, want := r.Get("zeta").Fields(), []string{"A", "B", "C"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("wrong fields, got %v, want %v", got, want)
+	}
+	var all []string
+	for _, o := range r.All() {
+		all = append(all, o.String())
+	}
+	if want := []string{"alpha", "alpha/one", "alpha/two", "beta", "beta/x/y", "zeta"}; !reflect.DeepEqual(all, want) {
+		t.Errorf("wrong All order, got %v, want %v", all, want)
+	}
+}
+
+func TestDepthFirst(t *testing.T) {
+	r := walkRegistry()
+
+	want := []string{"alpha", "alpha/one", "alpha/two", "beta", "beta/x", "beta/x/y", "zeta"}
+	if got := paths(r.Root().DepthFirst()); !reflect.DeepEqual(got, want) {
+		t.Errorf("wrong depth-first order, got %v, want %v", got, want)
+	}
+	if got := paths(r.Get("beta").DepthFirst()); !reflect.DeepEqual(got, []string{"beta/x", "beta/x/y"}) {
+		t.Errorf("wrong subtree, got %v", got)
+	}
+
+	var seen []string
+	for o := range r.Root().DepthFirst() {
+		seen = append(seen, o.String())
+		if o.String() == "alpha/one" {
+			break
+		}
+	}
+	if !reflect.DeepEqual(seen, []string{"alpha", "alpha/one"}) {
+		t.Errorf("early exit failed, got %v", seen)
+	}
+}
+
+func TestBreadthFirst(t *testing.T) {
+	r := walkRegistry()
+
+	want := []string{"alpha", "beta", "zeta", "alpha/one", "alpha/two", "beta/x", "beta/x/y"}
+	if got := paths(r.Root().BreadthFirst()); !reflect.DeepEqual(got, want) {
+		t.Errorf("wrong breadth-first order, got %v, want %v", got, want)
+	}
+
+	n := 0
+	for range r.Root().BreadthFirst() {
+		n++
+		if n == 2 {
+			break
+		}
+	}
+	if n != 2 {
+		t.Errorf("early exit failed after %d objects", n)
+	}
+	if got := paths((*pobj.Object)(nil).BreadthFirst()); got != nil {
+		t.Errorf("nil object should have no descendants, got %v", got)
+	}
+}
+
+func TestWalk(t *testing.T) {
+	r := walkRegistry()
+
+	var seen []string
+	err := r.Root().Walk(func(o *pobj.Object) error {
+		seen = append(seen, o.String())
+		if o.String() == "alpha" {
+			return pobj.SkipChildren
+		}
+		return nil
+	})
+	if err != nil || !reflect.DeepEqual(seen, []string{"alpha", "beta", "beta/x", "beta/x/y", "zeta"}) {
+		t.Errorf("wrong walk, got %v, %v", seen, err)
+	}
+
+	seen = nil
+	err = r.Get("beta").Walk(func(o *pobj.Object) error {
+		seen = append(seen, o.String())
+		if o.String() == "beta/x" {
+			return pobj.SkipAll
+		}
+		return nil
+	})
+	if err != nil || !reflect.DeepEqual(seen, []string{"beta", "beta/x"}) {
+		t.Errorf("wrong subtree walk, got %v, %v", seen, err)
+	}
+
+	if err := r.Root().Walk(func(o *pobj.Object) error { return pobj.ErrUnknownType }); err != pobj.ErrUnknownType {
+		t.Errorf("Walk should return the error of fn, got %v", err)
+	}
+
+	var desc []string
+	for _, o := range r.Get("alpha").Descendants() {
+		desc = append(desc, o.String())
+	}
+	if !reflect.DeepEqual(desc, []string{"alpha/one", "alpha/two"}) {
+		t.Errorf("wrong descendants, got %v", desc)
+	}
+}
+
+func TestFind(t *testing.T) {
+	r := walkRegistry()
+
+	tests := []struct {
+		pattern string
+		want    []string
+	}{
+		{"alpha/*", []string{"alpha/one", "alpha/two"}},
+		{"alpha/**", []string{"alpha", "alpha/one", "alpha/two"}},
+		{"**/y", []string{"beta/x/y"}},
+		{"beta/**/y", []string{"beta/x/y"}},
+		{"*", []string{"alpha", "beta", "zeta"}},
+		{"alpha/t*", []string{"alpha/two"}},
+		{"alpha/link", nil}, // aliases are not included
+		{"nope/**", nil},
+		{"[", nil},
+		{"/alpha/*", []string{"alpha/one", "alpha/two"}},
+		{"alpha/", []string{"alpha"}},
+		{"alpha//one", nil},
+		{"/", nil},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, o := range r.Find(tt.pattern) {
+			got = append(got, o.String())
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Find(%q) = %v, want %v", tt.pattern, got, tt.want)
+		}
+	}
+}
+
+func TestHierarchyAccessors(t *testing.T) {
+	r := walkRegistry()
+
+	y := r.Get("beta/x/y")
+	if got := y.Path(); !reflect.DeepEqual(got, []string{"beta", "x", "y"}) {
+		t.Errorf("wrong path, got %v", got)
+	}
+	if y.Depth() != 3 || r.Root().Depth() != 0 || r.Root().Path() != nil {
+		t.Errorf("wrong depths, got %d and %d", y.Depth(), r.Root().Depth())
+	}
+	if y.Parent() != r.Get("beta/x") || r.Get("beta").Parent() != r.Root() || r.Root().Parent() != nil {
+		t.Error("wrong parents")
+	}
+	if !y.HasType() || r.Get("beta/x").HasType() || r.Root().HasType() {
+		t.Error("only registered objects should have a type")
+	}
+	// an alias resolves to the object, with its primary parent
+	if r.Get("alpha/link").Parent() != r.Root() {
+		t.Error("alias should keep the primary parent")
+	}
+
+	var nilObj *pobj.Object
+	if nilObj.Parent() != nil || nilObj.Path() != nil || nilObj.Depth() != 0 || nilObj.HasType() {
+		t.Error("nil object accessors should return zero values")
+	}
+}